@@ -0,0 +1,37 @@
+package xsdvalidate
+
+// C14NMode is a canonical xml variant.
+type C14NMode int
+
+// The canonicalization modes.
+const (
+	C14N10          C14NMode = iota // Canonical XML 1.0
+	C14N11                          // Canonical XML 1.1
+	ExclusiveC14N10                 // Exclusive XML Canonicalization 1.0
+)
+
+// C14NOptions for canonicalizing the document of an XmlHandler.
+type C14NOptions struct {
+	WithComments      bool              // Keep comments
+	InclusivePrefixes []string          // Namespace prefixes treated as in inclusive canonicalization, ExclusiveC14N10 only, "#default" for the default namespace
+	XPath             string            // Canonicalize the node set selected by the expression instead of the whole document
+	Namespaces        map[string]string // Prefixes used in XPath, prefixes declared on the root element can be used without mapping them
+}
+
+// Canonicalize returns the canonical form of the document of the xmlHandler, e.g. for hashing or signing it.
+// With options.XPath only the selected nodes are output as document subset, an element without its
+// attributes, namespaces and descendants is output as empty tags. To canonicalize an element with its content
+// select it like "(//x:order//. | //x:order//@* | //x:order//namespace::*)".
+// Entity references are replaced by the content of their internal entities and default attributes of the internal subset
+// are added as canonical xml requires, the document of the xmlHandler is not modified. External entities are not loaded,
+// a document referencing one cannot be canonicalized.
+// If an error is returned it is of type Libxml2Error, XmlParserError or XPathError.
+func (xmlHandler *XmlHandler) Canonicalize(mode C14NMode, options C14NOptions) ([]byte, error) {
+	if !g.isInitialized() {
+		return nil, Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return nil, XmlParserError{errorMessage: errorMessage{"Xml handler not properly initialized", ErrHandlerNotInitialized}}
+	}
+	return canonicalize(xmlHandler, mode, options)
+}
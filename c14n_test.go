@@ -0,0 +1,87 @@
+//go:build apitest
+// +build apitest
+
+package xsdvalidate
+
+import (
+	"strings"
+	"testing"
+)
+
+const c14nXml = `<?xml version="1.0" encoding="UTF-8"?>
+<!-- orders -->
+<o:orders xmlns:o="urn:orders" xmlns:x="urn:unused">
+	<o:order b="2" a="1"><o:note/></o:order>
+</o:orders>`
+
+func TestCanonicalize(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xmlHandler, err := NewXmlHandlerMem([]byte(c14nXml), ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xmlHandler.Free()
+
+	tests := []struct {
+		mode    C14NMode
+		options C14NOptions
+		want    string
+	}{
+		{C14N10, C14NOptions{}, "<o:orders xmlns:o=\"urn:orders\" xmlns:x=\"urn:unused\">\n\t<o:order a=\"1\" b=\"2\"><o:note></o:note></o:order>\n</o:orders>"},
+		{C14N11, C14NOptions{WithComments: true}, "<!-- orders -->\n<o:orders xmlns:o=\"urn:orders\" xmlns:x=\"urn:unused\">\n\t<o:order a=\"1\" b=\"2\"><o:note></o:note></o:order>\n</o:orders>"},
+		{ExclusiveC14N10, C14NOptions{XPath: "(//o:order//. | //o:order//@* | //o:order//namespace::*)"},
+			"<o:order xmlns:o=\"urn:orders\" a=\"1\" b=\"2\"><o:note></o:note></o:order>"},
+		{ExclusiveC14N10, C14NOptions{XPath: "(//y:order//. | //y:order//@* | //y:order//namespace::*)", Namespaces: map[string]string{"y": "urn:orders"}, InclusivePrefixes: []string{"x"}},
+			"<o:order xmlns:o=\"urn:orders\" xmlns:x=\"urn:unused\" a=\"1\" b=\"2\"><o:note></o:note></o:order>"},
+	}
+	for _, test := range tests {
+		out, err := xmlHandler.Canonicalize(test.mode, test.options)
+		if err != nil {
+			t.Errorf("mode %d: unexpected error %v", test.mode, err)
+		} else if string(out) != test.want {
+			t.Errorf("mode %d: got %q, want %q", test.mode, out, test.want)
+		}
+	}
+
+	if _, err := xmlHandler.Canonicalize(C14N10, C14NOptions{XPath: "count(//o:order)"}); err == nil {
+		t.Error("expected XPathError for an expression not selecting nodes")
+	}
+}
+
+func TestCanonicalizeInternalSubset(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xmlHandler, err := NewXmlHandlerMem([]byte(`<?xml version="1.0"?>
+<!DOCTYPE o:order [
+	<!ENTITY company "ACME &amp; <o:b>Sons</o:b>">
+	<!ENTITY signature "&company; Ltd">
+	<!ATTLIST o:order status CDATA "new">
+]>
+<o:order xmlns:o="urn:orders" id="1">&signature;</o:order>`), ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xmlHandler.Free()
+
+	out, err := xmlHandler.Canonicalize(C14N10, C14NOptions{})
+	want := `<o:order xmlns:o="urn:orders" id="1" status="new">ACME &amp; <o:b>Sons</o:b> Ltd</o:order>`
+	if err != nil || string(out) != want {
+		t.Errorf("got %q %v, want %q", out, err, want)
+	}
+	out, err = xmlHandler.Canonicalize(ExclusiveC14N10, C14NOptions{XPath: "(//o:b//. | //o:b//namespace::*)"})
+	if err != nil || string(out) != `<o:b xmlns:o="urn:orders">Sons</o:b>` {
+		t.Errorf("unexpected subset %q %v", out, err)
+	}
+
+	external, err := NewXmlHandlerMem([]byte(`<!DOCTYPE a [<!ENTITY ext SYSTEM "c14n_test.go">]><a>&ext;</a>`), ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer external.Free()
+	if _, err := external.Canonicalize(C14N10, C14NOptions{}); err == nil || !strings.Contains(err.Error(), "'ext'") {
+		t.Errorf("expected an error naming the external entity, got %v", err)
+	}
+}
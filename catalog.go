@@ -0,0 +1,103 @@
+package xsdvalidate
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Catalog maps message keys to message templates used by StructError.Localized.
+// A key is a libxml2 error code like "1840", a code with variant for errors that share a code ("1871.unexpected" and "1871.missing",
+// "1824.attribute" for the value of an attribute instead of an element) or a category as returned by StructError.Category as fallback, e.g. "schema-validity".
+// Templates may contain the parameters {message}, {code}, {line}, {column}, {node}, {path}, {found}, {expected}, {suggestions},
+// {attribute}, {value}, {type}, {limit}, {rule} and {assertion}, attribute to limit are extracted from the libxml2 message and may be empty.
+type Catalog map[string]string
+
+var catalogs = struct {
+	sync.RWMutex
+	m map[string]Catalog
+}{m: map[string]Catalog{"en": englishCatalog, "de": germanCatalog}}
+
+// Returns a copy of a catalog.
+func (c Catalog) clone() Catalog {
+	cc := make(Catalog, len(c))
+	for key, tmpl := range c {
+		cc[key] = tmpl
+	}
+	return cc
+}
+
+// RegisterCatalog registers a copy of a message catalog for a language like "fr" or "de-CH", replacing a registered catalog of the same language.
+func RegisterCatalog(lang string, catalog Catalog) {
+	catalogs.Lock()
+	defer catalogs.Unlock()
+	catalogs.m[strings.ToLower(lang)] = catalog.clone()
+}
+
+// RegisteredCatalog returns a copy of the catalog registered for a language or nil, the builtin catalogs are "en" and "de".
+func RegisteredCatalog(lang string) Catalog {
+	catalogs.RLock()
+	defer catalogs.RUnlock()
+	if c, ok := catalogs.m[strings.ToLower(lang)]; ok {
+		return c.clone()
+	}
+	return nil
+}
+
+// Returns the catalogs to try for a language: the language itself, its base language and English.
+func lookupCatalogs(lang string) []Catalog {
+	catalogs.RLock()
+	defer catalogs.RUnlock()
+	lang = strings.ToLower(strings.Replace(lang, "_", "-", -1))
+	var cs []Catalog
+	for _, l := range []string{lang, strings.SplitN(lang, "-", 2)[0], "en"} {
+		if c, ok := catalogs.m[l]; ok {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+// Returns the catalog keys of a StructError, most specific first.
+func messageKeys(e StructError) []string {
+	code := strconv.Itoa(e.Code)
+	var keys []string
+	if e.Found != (QName{}) {
+		keys = append(keys, code+".unexpected")
+	} else if len(e.Expected) > 0 {
+		keys = append(keys, code+".missing")
+	} else if reAttributeValue.MatchString(e.Message) {
+		keys = append(keys, code+".attribute")
+	}
+	return append(keys, code, e.Category())
+}
+
+// Localized returns the message of the error in the given language, e.g. "de".
+// The catalog of the language, of its base language and the English catalog are searched in this order,
+// if no template matches the original libxml2 message is returned.
+func (e StructError) Localized(lang string) string {
+	keys := messageKeys(e)
+	for _, c := range lookupCatalogs(lang) {
+		for _, key := range keys {
+			if tmpl, ok := c[key]; ok {
+				params := messageParams(e)
+				oldnew := make([]string, 0, 2*len(params))
+				for name, value := range params {
+					oldnew = append(oldnew, "{"+name+"}", value)
+				}
+				return strings.NewReplacer(oldnew...).Replace(tmpl)
+			}
+		}
+	}
+	return e.Message
+}
+
+// Localized returns a copy of the ValidationError with the messages translated to the given language, see StructError.Localized.
+func (e ValidationError) Localized(lang string) ValidationError {
+	le := ValidationError{make([]StructError, len(e.Errors))}
+	for i, se := range e.Errors {
+		le.Errors[i] = se
+		le.Errors[i].Message = se.Localized(lang)
+	}
+	return le
+}
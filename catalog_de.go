@@ -0,0 +1,33 @@
+package xsdvalidate
+
+// germanCatalog is the builtin German message catalog.
+var germanCatalog = Catalog{
+	"1824":            "Element '{node}': '{value}' ist kein gültiger Wert des Typs '{type}'.",
+	"1824.attribute":  "Element '{node}', Attribut '{attribute}': '{value}' ist kein gültiger Wert des Typs '{type}'.",
+	"1830":            "Element '{node}': Der Wert hat nicht die erforderliche Länge von {limit}.",
+	"1830.attribute":  "Element '{node}', Attribut '{attribute}': Der Wert hat nicht die erforderliche Länge von {limit}.",
+	"1831":            "Element '{node}': Der Wert ist kürzer als die Mindestlänge von {limit}.",
+	"1831.attribute":  "Element '{node}', Attribut '{attribute}': Der Wert ist kürzer als die Mindestlänge von {limit}.",
+	"1832":            "Element '{node}': Der Wert ist länger als die Höchstlänge von {limit}.",
+	"1832.attribute":  "Element '{node}', Attribut '{attribute}': Der Wert ist länger als die Höchstlänge von {limit}.",
+	"1833":            "Element '{node}': Der Wert '{value}' ist kleiner als das Minimum {limit}.",
+	"1833.attribute":  "Element '{node}', Attribut '{attribute}': Der Wert '{value}' ist kleiner als das Minimum {limit}.",
+	"1834":            "Element '{node}': Der Wert '{value}' ist größer als das Maximum {limit}.",
+	"1834.attribute":  "Element '{node}', Attribut '{attribute}': Der Wert '{value}' ist größer als das Maximum {limit}.",
+	"1835":            "Element '{node}': Der Wert '{value}' muss größer als {limit} sein.",
+	"1835.attribute":  "Element '{node}', Attribut '{attribute}': Der Wert '{value}' muss größer als {limit} sein.",
+	"1836":            "Element '{node}': Der Wert '{value}' muss kleiner als {limit} sein.",
+	"1836.attribute":  "Element '{node}', Attribut '{attribute}': Der Wert '{value}' muss kleiner als {limit} sein.",
+	"1839":            "Element '{node}': Der Wert '{value}' entspricht nicht dem Muster '{limit}'.",
+	"1839.attribute":  "Element '{node}', Attribut '{attribute}': Der Wert '{value}' entspricht nicht dem Muster '{limit}'.",
+	"1840":            "Element '{node}': Der Wert '{value}' ist keiner der erlaubten Werte {limit}.",
+	"1840.attribute":  "Element '{node}', Attribut '{attribute}': Der Wert '{value}' ist keiner der erlaubten Werte {limit}.",
+	"1845":            "Element '{node}': Für das Wurzelelement gibt es keine passende globale Deklaration.",
+	"1866":            "Element '{node}': Das Attribut '{attribute}' ist nicht erlaubt.",
+	"1868":            "Element '{node}': Das Pflichtattribut '{attribute}' fehlt.",
+	"1871.unexpected": "Element '{found}' ist an dieser Stelle nicht erlaubt. Erwartet wird ( {expected} ).",
+	"1871.missing":    "Element '{node}': Kindelement(e) fehlen. Erwartet wird ( {expected} ).",
+	"schema-validity": "Element '{node}' ist ungültig (Fehlercode {code}).",
+	"parser":          "Das XML-Dokument ist nicht wohlgeformt (Zeile {line}).",
+	"libxml2":         "Interner Fehler bei der XML-Verarbeitung.",
+}
@@ -0,0 +1,33 @@
+package xsdvalidate
+
+// englishCatalog is the builtin English message catalog, it is also the fallback for other languages.
+var englishCatalog = Catalog{
+	"1824":            "Element '{node}': '{value}' is not a valid value of the type '{type}'.",
+	"1824.attribute":  "Element '{node}', attribute '{attribute}': '{value}' is not a valid value of the type '{type}'.",
+	"1830":            "Element '{node}': The value does not have the required length of {limit}.",
+	"1830.attribute":  "Element '{node}', attribute '{attribute}': The value does not have the required length of {limit}.",
+	"1831":            "Element '{node}': The value is shorter than the minimum length of {limit}.",
+	"1831.attribute":  "Element '{node}', attribute '{attribute}': The value is shorter than the minimum length of {limit}.",
+	"1832":            "Element '{node}': The value is longer than the maximum length of {limit}.",
+	"1832.attribute":  "Element '{node}', attribute '{attribute}': The value is longer than the maximum length of {limit}.",
+	"1833":            "Element '{node}': The value '{value}' is less than the minimum {limit}.",
+	"1833.attribute":  "Element '{node}', attribute '{attribute}': The value '{value}' is less than the minimum {limit}.",
+	"1834":            "Element '{node}': The value '{value}' is greater than the maximum {limit}.",
+	"1834.attribute":  "Element '{node}', attribute '{attribute}': The value '{value}' is greater than the maximum {limit}.",
+	"1835":            "Element '{node}': The value '{value}' must be greater than {limit}.",
+	"1835.attribute":  "Element '{node}', attribute '{attribute}': The value '{value}' must be greater than {limit}.",
+	"1836":            "Element '{node}': The value '{value}' must be less than {limit}.",
+	"1836.attribute":  "Element '{node}', attribute '{attribute}': The value '{value}' must be less than {limit}.",
+	"1839":            "Element '{node}': The value '{value}' does not match the pattern '{limit}'.",
+	"1839.attribute":  "Element '{node}', attribute '{attribute}': The value '{value}' does not match the pattern '{limit}'.",
+	"1840":            "Element '{node}': The value '{value}' is not one of the allowed values {limit}.",
+	"1840.attribute":  "Element '{node}', attribute '{attribute}': The value '{value}' is not one of the allowed values {limit}.",
+	"1845":            "Element '{node}': No matching global declaration available for the validation root.",
+	"1866":            "Element '{node}': The attribute '{attribute}' is not allowed.",
+	"1868":            "Element '{node}': The attribute '{attribute}' is required but missing.",
+	"1871.unexpected": "Element '{found}' is not expected here. Expected is ( {expected} ).",
+	"1871.missing":    "Element '{node}': Missing child element(s). Expected is ( {expected} ).",
+	"schema-validity": "{message}",
+	"parser":          "{message}",
+	"libxml2":         "{message}",
+}
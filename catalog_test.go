@@ -0,0 +1,96 @@
+//go:build apitest
+// +build apitest
+
+package xsdvalidate
+
+import (
+	"testing"
+)
+
+func TestLocalized(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsd := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+	<xs:element name="order">
+		<xs:complexType>
+			<xs:sequence>
+				<xs:element name="color">
+					<xs:simpleType>
+						<xs:restriction base="xs:string">
+							<xs:enumeration value="red"/>
+							<xs:enumeration value="green"/>
+						</xs:restriction>
+					</xs:simpleType>
+				</xs:element>
+				<xs:element name="quantity" type="xs:int"/>
+			</xs:sequence>
+			<xs:attribute name="id" type="xs:int" use="required"/>
+		</xs:complexType>
+	</xs:element>
+</xs:schema>`)
+
+	xsdhandler, err := NewXsdHandlerMem(xsd, ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	tests := []struct {
+		xml string
+		en  string
+		de  string
+	}{
+		{`<order id="1"><color>blue</color><quantity>1</quantity></order>`,
+			"Element 'color': The value 'blue' is not one of the allowed values 'red', 'green'.",
+			"Element 'color': Der Wert 'blue' ist keiner der erlaubten Werte 'red', 'green'."},
+		{`<order id="1"><color>red</color><quantity>x</quantity></order>`,
+			"Element 'quantity': 'x' is not a valid value of the type 'xs:int'.",
+			"Element 'quantity': 'x' ist kein gültiger Wert des Typs 'xs:int'."},
+		{`<order id="x"><color>red</color><quantity>1</quantity></order>`,
+			"Element 'order', attribute 'id': 'x' is not a valid value of the type 'xs:int'.",
+			"Element 'order', Attribut 'id': 'x' ist kein gültiger Wert des Typs 'xs:int'."},
+		{`<order><color>red</color><quantity>1</quantity></order>`,
+			"Element 'order': The attribute 'id' is required but missing.",
+			"Element 'order': Das Pflichtattribut 'id' fehlt."},
+		{`<order id="1"><quantity>1</quantity></order>`,
+			"Element 'quantity' is not expected here. Expected is ( color ).",
+			"Element 'quantity' ist an dieser Stelle nicht erlaubt. Erwartet wird ( color )."},
+		{`<order id="1"><color>red</color></order>`,
+			"Element 'order': Missing child element(s). Expected is ( quantity ).",
+			"Element 'order': Kindelement(e) fehlen. Erwartet wird ( quantity )."},
+	}
+	for _, tc := range tests {
+		err = xsdhandler.ValidateMem([]byte(tc.xml), ParsErrDefault)
+		ve, ok := err.(ValidationError)
+		if !ok {
+			t.Fatalf("expected ValidationError, got %v", err)
+		}
+		if got := ve.Errors[0].Localized("en-US"); got != tc.en {
+			t.Errorf("%s: unexpected english message %q", ve.Errors[0].Message, got)
+		}
+		if got := ve.Localized("de_CH").Errors[0].Message; got != tc.de {
+			t.Errorf("%s: unexpected german message %q", ve.Errors[0].Message, got)
+		}
+	}
+
+	fr := Catalog{"schema-validity": "Erreur de validation ligne {line}."}
+	RegisterCatalog("fr", fr)
+	fr["schema-validity"] = "changed"
+	se := StructError{Code: 1871, Line: 7, Domain: DomainSchemasValid, Message: "Element 'x': Something."}
+	if got := se.Localized("fr"); got != "Erreur de validation ligne 7." {
+		t.Errorf("unexpected french message %q", got)
+	}
+	en := RegisteredCatalog("en")
+	en["schema-validity"] = "changed"
+	if got := se.Localized("en"); got != se.Message {
+		t.Errorf("unexpected english message %q", got)
+	}
+	if RegisteredCatalog("xx") != nil {
+		t.Error("expected no catalog")
+	}
+	if got := se.Localized("xx"); got != se.Message {
+		t.Errorf("unexpected fallback message %q", got)
+	}
+}
@@ -1,13 +1,47 @@
 package xsdvalidate
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
 
+// Sentinel errors wrapped by Libxml2Error, XmlParserError and XsdParserError, use errors.Is to check for them.
+var (
+	ErrAlreadyInitialized    = errors.New("libxml2 already initialized")
+	ErrNotInitialized        = errors.New("libxml2 not initialized")
+	ErrHandlerNotInitialized = errors.New("handler not properly initialized")
+	ErrRootNotAllowed        = errors.New("root element not allowed")
+)
+
+// Error levels of StructError, see libxml2's xmlErrorLevel.
+const (
+	LevelNone    = 0
+	LevelWarning = 1
+	LevelError   = 2
+	LevelFatal   = 3
+)
+
+// Error domains of StructError, a subset of libxml2's xmlErrorDomain, DomainRule is used for the errors of Go rules.
+const (
+	DomainParser          = 1
+	DomainNamespace       = 3
+	DomainDtd             = 4
+	DomainXInclude        = 11
+	DomainXPath           = 12
+	DomainSchemasParser   = 16
+	DomainSchemasValid    = 17
+	DomainRelaxNGParser   = 18
+	DomainRelaxNGValid    = 19
+	DomainValid           = 23
+	DomainSchematronValid = 28
+	DomainRule            = 1000
+)
+
 // Common String and Error implementations.
 type errorMessage struct {
 	Message string
+	err     error
 }
 
 // Implementation of the Stringer Interface.
@@ -20,6 +54,11 @@ func (e errorMessage) Error() string {
 	return e.String()
 }
 
+// Unwrap returns the wrapped sentinel error if there is one.
+func (e errorMessage) Unwrap() error {
+	return e.err
+}
+
 // Libxml2Error is returned when a Libxm2 initialization error occured.
 type Libxml2Error struct {
 	errorMessage
@@ -28,6 +67,7 @@ type Libxml2Error struct {
 // XmlParserError is returned when xml parsing caused error(s).
 type XmlParserError struct {
 	errorMessage
+	columns string // columns of the errors with a line in Message, separated by spaces
 }
 
 // XsdParserError is returned when xsd parsing caused a error(s).
@@ -35,13 +75,112 @@ type XsdParserError struct {
 	errorMessage
 }
 
+// RngParserError is returned when RelaxNG grammar parsing caused error(s).
+type RngParserError struct {
+	errorMessage
+}
+
+// SchematronParserError is returned when schematron schema parsing caused error(s).
+type SchematronParserError struct {
+	errorMessage
+}
+
+// DtdParserError is returned when DTD parsing caused error(s).
+type DtdParserError struct {
+	errorMessage
+}
+
+// XPathError is returned when an xpath expression is invalid or selects nothing.
+type XPathError struct {
+	errorMessage
+}
+
+// RootElementError is returned when the root element of a document is not one of the allowed root elements, see XsdHandler.SetRootElements.
+type RootElementError struct {
+	errorMessage
+	Root    QName
+	Allowed []QName
+}
+
 // StructError is a subset of libxml2 xmlError struct.
+// File is the file or URI of the document the error occurred in if it is known, for XIncluded content the included file.
+// Domain is the libxml2 module that reported the error, Path the XPath-like location of the offending node.
+// Expected and Found are filled in for content model errors like "This element is not expected. Expected is ( orderperson ).",
+// Found is only set when an element was encountered at a position where it is not allowed.
+// Suggestions holds the closest allowed enumeration values or element names for misspelled values and elements.
+// Value is the offending document value quoted in Message, if any, it is kept when Message is redacted.
+// Stage is the name of the Pipeline stage that reported the error.
+// Rule and Assertion are the rule context and the assertion id of a failed schematron assert or successful report,
+// for errors of Rules Rule is the selector of the failed rule.
 type StructError struct {
-	Code     int
-	Message  string
-	Level    int
-	Line     int
-	NodeName string
+	Code        int
+	Message     string
+	Level       int
+	File        string
+	Line        int
+	Column      int
+	Domain      int
+	NodeName    string
+	Path        string
+	Value       string
+	Expected    []QName
+	Found       QName
+	Suggestions []string
+	Rule        string
+	Assertion   string
+	Stage       string
+}
+
+// Implementation of the Stringer interface.
+func (e StructError) String() string {
+	return fmt.Sprintf("%d: %s", e.Line, e.Message)
+}
+
+// Implementation of the Error interface.
+func (e StructError) Error() string {
+	return e.String()
+}
+
+// Category returns a short name for the libxml2 module that reported the error.
+func (e StructError) Category() string {
+	switch e.Domain {
+	case DomainParser:
+		return "parser"
+	case DomainNamespace:
+		return "namespace"
+	case DomainDtd, DomainValid:
+		return "dtd"
+	case DomainXInclude:
+		return "xinclude"
+	case DomainXPath:
+		return "xpath"
+	case DomainSchemasParser:
+		return "schema-parser"
+	case DomainSchemasValid:
+		return "schema-validity"
+	case DomainRelaxNGParser:
+		return "relaxng-parser"
+	case DomainRelaxNGValid:
+		return "relaxng-validity"
+	case DomainSchematronValid:
+		return "schematron"
+	case DomainRule:
+		return "rule"
+	default:
+		return "libxml2"
+	}
+}
+
+// Returns the name of an error level.
+func levelName(level int) string {
+	switch level {
+	case LevelWarning:
+		return "warning"
+	case LevelFatal:
+		return "fatal"
+	default:
+		return "error"
+	}
 }
 
 // ValidationError is returned when xsd validation caused an error, to access the fields of the Errors slice use type assertion (see example).
@@ -53,7 +192,7 @@ type ValidationError struct {
 func (e ValidationError) String() string {
 	var em string
 	for _, eelem := range e.Errors {
-		em = em + fmt.Sprintf("%d: %s\n", eelem.Line, eelem.Message)
+		em = em + eelem.String() + "\n"
 	}
 	return strings.TrimRight(em, "\n")
 }
@@ -62,3 +201,50 @@ func (e ValidationError) String() string {
 func (e ValidationError) Error() string {
 	return e.String()
 }
+
+// Unwrap returns the Errors slice as errors, errors.As follows it from go 1.20 on.
+func (e ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, eelem := range e.Errors {
+		errs[i] = eelem
+	}
+	return errs
+}
+
+// As finds the first of the Errors that matches target, so errors.As can be used to find a StructError before go 1.20 too.
+func (e ValidationError) As(target interface{}) bool {
+	for _, eelem := range e.Errors {
+		if errors.As(eelem, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the errors of a ValidationError, XmlParserError, XsdParserError, RngParserError, SchematronParserError or DtdParserError as StructError slice, other errors are returned as a single StructError.
+func structErrors(err error) []StructError {
+	switch e := err.(type) {
+	case ValidationError:
+		return e.Errors
+	case StructError:
+		return []StructError{e}
+	case XmlParserError:
+		errs := parserStructErrors(e.Message, DomainParser)
+		if e.columns != "" {
+			setColumns(errs, e.columns)
+		}
+		return errs
+	case XsdParserError:
+		return parserStructErrors(e.Message, DomainSchemasParser)
+	case RngParserError:
+		return parserStructErrors(e.Message, DomainRelaxNGParser)
+	case SchematronParserError:
+		return parserStructErrors(e.Message, DomainSchematronValid)
+	case DtdParserError:
+		return parserStructErrors(e.Message, DomainDtd)
+	case nil:
+		return nil
+	default:
+		return []StructError{{Message: e.Error(), Level: LevelFatal}}
+	}
+}
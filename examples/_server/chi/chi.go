@@ -3,7 +3,6 @@ package main
 
 import (
 	"context"
-	"encoding/xml"
 	"fmt"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
@@ -17,10 +16,12 @@ var xsdHandler *xsdvalidate.XsdHandler
 
 func readBody(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/xml; charset=utf-8")
 		body, err := ioutil.ReadAll(r.Body)
 
 		if err != nil {
-			fmt.Fprintf(w, fmt.Sprintf("%s<error><![CDATA[%s]]></error>", xml.Header, err))
+			w.WriteHeader(http.StatusBadRequest)
+			xsdvalidate.WriteReport(w, err)
 			return
 		}
 
@@ -36,7 +37,8 @@ func validateBody(next http.Handler) http.Handler {
 
 		err := xsdHandler.ValidateMem(body, xsdvalidate.ParsErrVerbose)
 		if err != nil {
-			fmt.Fprintf(w, fmt.Sprintf("%s<error><![CDATA[%s]]></error>", xml.Header, err))
+			w.WriteHeader(http.StatusBadRequest)
+			xsdvalidate.WriteReport(w, err)
 			return
 		}
 
@@ -63,10 +65,7 @@ func main() {
 
 	r.Route("/", func(r chi.Router) {
 		r.Post("/address", func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("content-type", "application/xml; charset=utf-8")
-			ctx := r.Context()
-			body := (ctx.Value("body").([]byte))
-			fmt.Fprintf(w, fmt.Sprintf("%s<request-content><![CDATA[%s]]></request-content><no-error>No errors</no-error>", xml.Header, body))
+			xsdvalidate.WriteReport(w, nil)
 		})
 	})
 
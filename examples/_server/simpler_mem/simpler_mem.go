@@ -3,7 +3,6 @@ package main
 
 import (
 	_ "embed"
-	"encoding/xml"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -26,17 +25,19 @@ func handler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("content-type", "application/xml; charset=utf-8")
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		fmt.Fprintf(w, fmt.Sprintf("%s<error><![CDATA[%s]]></error>", xml.Header, err))
+		w.WriteHeader(http.StatusBadRequest)
+		xsdvalidate.WriteReport(w, err)
 		return
 	}
 
 	err = xsdHandler.ValidateMem(body, xsdvalidate.ParsErrVerbose)
 	if err != nil {
-		fmt.Fprintf(w, fmt.Sprintf("%s<error><![CDATA[%s]]></error>", xml.Header, err))
+		w.WriteHeader(http.StatusBadRequest)
+		xsdvalidate.WriteReport(w, err)
 		return
 	}
 
-	fmt.Fprintf(w, fmt.Sprintf("%s<no-error>No errors</no-error>", xml.Header))
+	xsdvalidate.WriteReport(w, nil)
 }
 
 func main() {
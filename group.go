@@ -0,0 +1,59 @@
+package xsdvalidate
+
+import "fmt"
+
+// ErrorGroup is a cluster of StructErrors sharing code, schema path and message template, see ValidationError.Grouped.
+type ErrorGroup struct {
+	Code      int         `json:"code"`
+	Path      string      `json:"path,omitempty"`
+	Template  string      `json:"template"`
+	Count     int         `json:"count"`
+	FirstLine int         `json:"firstLine"`
+	LastLine  int         `json:"lastLine"`
+	Example   StructError `json:"example"`
+}
+
+// Implementation of the Stringer interface.
+func (g ErrorGroup) String() string {
+	if g.Count == 1 {
+		return g.Example.String()
+	}
+	return fmt.Sprintf("%d-%d: %s (%d times)", g.FirstLine, g.LastLine, g.Example.Message, g.Count)
+}
+
+// Grouped clusters the Errors slice by error code, schema path (the node path without positions) and message template
+// (the message with quoted names and values replaced), groups are ordered by first occurrence.
+// Example is the first error of the group.
+func (e ValidationError) Grouped() []ErrorGroup {
+	type groupKey struct {
+		code     int
+		path     string
+		template string
+	}
+	var groups []ErrorGroup
+	index := make(map[groupKey]int)
+	for _, se := range e.Errors {
+		key := groupKey{se.Code, schemaPath(se.Path), messageTemplate(se.Message)}
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(groups)
+			groups = append(groups, ErrorGroup{
+				Code:      key.code,
+				Path:      key.path,
+				Template:  key.template,
+				FirstLine: se.Line,
+				Example:   se,
+			})
+			i = len(groups) - 1
+		}
+		g := &groups[i]
+		g.Count++
+		if se.Line < g.FirstLine {
+			g.FirstLine = se.Line
+		}
+		if se.Line > g.LastLine {
+			g.LastLine = se.Line
+		}
+	}
+	return groups
+}
@@ -0,0 +1,66 @@
+//go:build apitest
+// +build apitest
+
+package xsdvalidate
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestValidationErrorGrouped(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsd := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+	<xs:element name="order">
+		<xs:complexType>
+			<xs:sequence>
+				<xs:element name="item" maxOccurs="unbounded">
+					<xs:complexType>
+						<xs:sequence>
+							<xs:element name="title" type="xs:string"/>
+							<xs:element name="price" type="xs:decimal"/>
+						</xs:sequence>
+					</xs:complexType>
+				</xs:element>
+			</xs:sequence>
+		</xs:complexType>
+	</xs:element>
+</xs:schema>`)
+
+	xsdhandler, err := NewXsdHandlerMem(xsd, ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	var sb strings.Builder
+	sb.WriteString("<order>\n")
+	for i := 0; i < 100; i++ {
+		sb.WriteString(fmt.Sprintf("<item><title>x</title><price>p%d</price></item>\n", i))
+	}
+	sb.WriteString("<item><title>x</title></item>\n</order>")
+
+	err = xsdhandler.ValidateMem([]byte(sb.String()), ParsErrDefault)
+	ve, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	groups := ve.Grouped()
+	if len(ve.Errors) != 101 || len(groups) != 2 {
+		t.Fatalf("expected 101 errors in 2 groups, got %d in %d", len(ve.Errors), len(groups))
+	}
+	g := groups[0]
+	if g.Count != 100 || g.FirstLine != 2 || g.LastLine != 101 || g.Path != "/order/item/price" {
+		t.Errorf("unexpected group %+v", g)
+	}
+	if g.String() != "2-101: "+g.Example.Message+" (100 times)" {
+		t.Errorf("unexpected group string %s", g)
+	}
+	if groups[1].Count != 1 || groups[1].String() != groups[1].Example.String() {
+		t.Errorf("unexpected group %+v", groups[1])
+	}
+}
@@ -0,0 +1,134 @@
+package xsdvalidate
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details documents.
+const ProblemContentType = "application/problem+json"
+
+// The stable json representation of a StructError.
+type jsonStructError struct {
+	File        string   `json:"file,omitempty"`
+	Line        int      `json:"line"`
+	Column      int      `json:"column"`
+	Path        string   `json:"path,omitempty"`
+	Node        string   `json:"node,omitempty"`
+	Code        int      `json:"code"`
+	Level       string   `json:"level"`
+	Category    string   `json:"category"`
+	Message     string   `json:"message"`
+	Expected    []string `json:"expected,omitempty"`
+	Found       string   `json:"found,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+	Rule        string   `json:"rule,omitempty"`
+	Assertion   string   `json:"assertion,omitempty"`
+	Stage       string   `json:"stage,omitempty"`
+}
+
+// The json representation of ValidationError, XmlParserError and XsdParserError.
+type jsonErrors struct {
+	Errors []StructError `json:"errors"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (e StructError) MarshalJSON() ([]byte, error) {
+	je := jsonStructError{
+		File:        e.File,
+		Line:        e.Line,
+		Column:      e.Column,
+		Path:        e.Path,
+		Node:        e.NodeName,
+		Code:        e.Code,
+		Level:       levelName(e.Level),
+		Category:    e.Category(),
+		Message:     e.Message,
+		Suggestions: e.Suggestions,
+		Rule:        e.Rule,
+		Assertion:   e.Assertion,
+		Stage:       e.Stage,
+	}
+	for _, q := range e.Expected {
+		je.Expected = append(je.Expected, q.String())
+	}
+	if e.Found != (QName{}) {
+		je.Found = e.Found.String()
+	}
+	return json.Marshal(je)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (e ValidationError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonErrors{structErrors(e)})
+}
+
+// MarshalJSON implements the json.Marshaler interface, line numbers are only available if parsed with ParsErrVerbose.
+func (e XmlParserError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonErrors{structErrors(e)})
+}
+
+// MarshalJSON implements the json.Marshaler interface, line numbers are only available if parsed with ParsErrVerbose.
+func (e XsdParserError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonErrors{structErrors(e)})
+}
+
+// MarshalJSON implements the json.Marshaler interface, line numbers are only available if parsed with ParsErrVerbose.
+func (e RngParserError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonErrors{structErrors(e)})
+}
+
+// MarshalJSON implements the json.Marshaler interface, line numbers are only available if parsed with ParsErrVerbose.
+func (e SchematronParserError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonErrors{structErrors(e)})
+}
+
+// MarshalJSON implements the json.Marshaler interface, line numbers are only available if parsed with ParsErrVerbose.
+func (e DtdParserError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonErrors{structErrors(e)})
+}
+
+// Problem is an RFC 7807 problem details document, the errors are added as "errors" extension member.
+type Problem struct {
+	Type     string        `json:"type,omitempty"`
+	Title    string        `json:"title"`
+	Status   int           `json:"status,omitempty"`
+	Detail   string        `json:"detail,omitempty"`
+	Instance string        `json:"instance,omitempty"`
+	Errors   []StructError `json:"errors,omitempty"`
+}
+
+// NewProblem creates a Problem from an error returned by this package, status is the http status code of the response.
+// Type and Instance are left empty and can be set by the caller.
+func NewProblem(err error, status int) Problem {
+	p := Problem{Status: status, Errors: structErrors(err)}
+	switch err.(type) {
+	case ValidationError:
+		p.Title = "Xml validation failed"
+	case XmlParserError:
+		p.Title = "Malformed xml document"
+	case XsdParserError:
+		p.Title = "Malformed xsd schema"
+	case RngParserError:
+		p.Title = "Malformed rng grammar"
+	case SchematronParserError:
+		p.Title = "Malformed schematron schema"
+	case DtdParserError:
+		p.Title = "Malformed dtd"
+	case Libxml2Error:
+		p.Title = "Libxml2 error"
+	default:
+		p.Title = "Validation error"
+	}
+	if len(p.Errors) > 0 {
+		p.Detail = fmt.Sprintf("%d error(s) found", len(p.Errors))
+	} else if err != nil {
+		p.Detail = err.Error()
+	}
+	return p
+}
+
+// ProblemJSON renders an error as RFC 7807 problem+json document, see NewProblem.
+func ProblemJSON(err error, status int) ([]byte, error) {
+	return json.Marshal(NewProblem(err, status))
+}
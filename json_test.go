@@ -0,0 +1,67 @@
+//go:build apitest
+// +build apitest
+
+package xsdvalidate
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidationErrorJSON(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsdhandler, err := NewXsdHandlerUrl("examples/test1_split.xsd", ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	err = xsdhandler.ValidateMem([]byte("<shiporder orderid=\"1\">\n<shipto/></shiporder>"), ParsErrDefault)
+	b, jerr := json.Marshal(err)
+	if jerr != nil {
+		t.Fatal(jerr)
+	}
+	want := `{"errors":[{"line":2,"column":0,"path":"/shiporder/shipto","node":"shipto","code":1871,"level":"error","category":"schema-validity","message":"Element 'shipto': This element is not expected. Expected is ( orderperson ).","expected":["orderperson"],"found":"shipto"}]}`
+	if string(b) != want {
+		t.Errorf("unexpected json:\n%s\nwant:\n%s", b, want)
+	}
+}
+
+func TestXmlParserErrorJSON(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	_, err := NewXmlHandlerMem([]byte("<a>\n<b></c></a>"), ParsErrVerbose)
+	b, jerr := json.Marshal(err)
+	if jerr != nil {
+		t.Fatal(jerr)
+	}
+	want := `{"errors":[{"line":2,"column":8,"code":0,"level":"fatal","category":"parser","message":"Opening and ending tag mismatch: b line 2 and c"}]}`
+	if string(b) != want {
+		t.Errorf("unexpected json:\n%s\nwant:\n%s", b, want)
+	}
+}
+
+func TestProblemJSON(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	_, err := NewXmlHandlerMem([]byte("<a>"), ParsErrDefault)
+	b, jerr := ProblemJSON(err, 400)
+	if jerr != nil {
+		t.Fatal(jerr)
+	}
+	var p map[string]interface{}
+	if err := json.Unmarshal(b, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p["title"] != "Malformed xml document" || p["status"] != float64(400) {
+		t.Errorf("unexpected problem %s", b)
+	}
+	if errs, ok := p["errors"].([]interface{}); !ok || len(errs) != 1 || !strings.Contains(string(b), `"message":"Malformed xml document"`) {
+		t.Errorf("unexpected problem errors %s", b)
+	}
+}
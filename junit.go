@@ -0,0 +1,106 @@
+package xsdvalidate
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// Formats a duration as seconds like JUnit does.
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// MarshalJUnit encodes the results of a batch of documents as JUnit xml report with one testsuite and one testcase per document.
+// ValidationError and XmlParserError results are reported as failures listing all StructErrors, other errors as errors.
+// StructErrors of another file than the document, like an XIncluded file, are prefixed with the file.
+func MarshalJUnit(suite string, results []DocumentResult) ([]byte, error) {
+	ts := junitTestSuite{Name: suite, Tests: len(results)}
+	var total time.Duration
+	for _, dr := range results {
+		total += dr.Duration
+		tc := junitTestCase{Name: dr.Name, ClassName: suite, Time: junitTime(dr.Duration)}
+		if dr.Err != nil {
+			errs := structErrors(dr.Err)
+			lines := make([]string, len(errs))
+			for i, e := range errs {
+				lines[i] = e.String()
+				if e.File != "" && filepath.ToSlash(e.File) != filepath.ToSlash(dr.Name) {
+					lines[i] = e.File + ":" + lines[i]
+				}
+			}
+			f := &junitFailure{
+				Message: fmt.Sprintf("%d error(s) found", len(errs)),
+				Type:    reportType(dr.Err),
+				Text:    strings.Join(lines, "\n"),
+			}
+			switch dr.Err.(type) {
+			case ValidationError, XmlParserError:
+				tc.Failure = f
+				ts.Failures++
+			default:
+				tc.Error = f
+				ts.Errors++
+			}
+		}
+		ts.TestCases = append(ts.TestCases, tc)
+	}
+	ts.Time = junitTime(total)
+
+	b, err := xml.MarshalIndent(junitTestSuites{
+		Tests:    ts.Tests,
+		Failures: ts.Failures,
+		Errors:   ts.Errors,
+		Time:     ts.Time,
+		Suites:   []junitTestSuite{ts},
+	}, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+// WriteJUnit writes the JUnit xml report of the results to w, see MarshalJUnit.
+func WriteJUnit(w io.Writer, suite string, results []DocumentResult) error {
+	b, err := MarshalJUnit(suite, results)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
@@ -0,0 +1,78 @@
+//go:build apitest
+// +build apitest
+
+package xsdvalidate
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestMarshalJUnit(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsdhandler, err := NewXsdHandlerUrl("examples/test1_split.xsd", ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	var results []DocumentResult
+	for _, name := range []string{"examples/test1_pass.xml", "examples/test1_fail2.xml", "examples/test1_fail1.xml"} {
+		inXml, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, Measure(name, func() error {
+			return xsdhandler.ValidateMem(inXml, ParsErrVerbose)
+		}))
+	}
+
+	b, err := MarshalJUnit("shiporder", results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report junitTestSuites
+	if err := xml.Unmarshal(b, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Tests != 3 || report.Failures != 2 || report.Errors != 0 {
+		t.Fatalf("unexpected report %s", b)
+	}
+	cases := report.Suites[0].TestCases
+	if cases[0].Failure != nil || cases[1].Failure == nil || cases[1].Failure.Type != "validation" ||
+		cases[1].Failure.Text != "3: Element 'shipto': This element is not expected. Expected is ( orderperson )." {
+		t.Errorf("unexpected testcases %s", b)
+	}
+}
+
+func TestMarshalJUnitXInclude(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsdhandler, err := NewXsdHandlerUrl("examples/test1_pass.xsd", ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+	xmlhandler, err := NewXmlHandlerUrl("examples/test1_xinclude.xml", ParsErrDefault|ParsXInclude)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xmlhandler.Free()
+
+	b, err := MarshalJUnit("shiporder", []DocumentResult{{Name: "examples/test1_xinclude.xml", Err: xsdhandler.Validate(xmlhandler, ValidErrDefault)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report junitTestSuites
+	if err := xml.Unmarshal(b, &report); err != nil {
+		t.Fatal(err)
+	}
+	if f := report.Suites[0].TestCases[0].Failure; f == nil || !strings.HasPrefix(f.Text, "examples/test1_xinclude_item.xml:6: ") {
+		t.Errorf("expected failure in the included file, got %s", b)
+	}
+}
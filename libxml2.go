@@ -7,12 +7,32 @@ package xsdvalidate
 #include <sys/time.h>
 #include <errno.h>
 #include <libxml/xmlschemastypes.h>
+#include <libxml/relaxng.h>
+#include <libxml/parser.h>
+#include <libxml/parserInternals.h>
+#include <libxml/uri.h>
+#include <libxml/xpath.h>
+#include <libxml/xpathInternals.h>
+#include <libxml/chvalid.h>
+#include <libxml/xmlsave.h>
+#include <libxml/c14n.h>
+#include <libxml/xinclude.h>
 #include <stdbool.h>
 #include <stdlib.h>
 #include <string.h>
 #define GO_ERR_INIT 1024
+#define XSD_NS "http://www.w3.org/2001/XMLSchema"
+#define MAX_SCHEMA_DEPTH 16
 #define P_ERR_DEFAULT 1
 #define P_ERR_VERBOSE 2
+#define P_XINCLUDE 4
+#define V_ADD_DEFAULTS 16
+#define V_LAX_FOREIGN 32
+#define V_WARN_FOREIGN 64
+#define LAX_MAX_PASSES 16
+#define XSI_NS "http://www.w3.org/2001/XMLSchema-instance"
+#define SCT_NS "http://purl.oclc.org/dsdl/schematron"
+#define SCT_OLD_NS "http://www.ascc.net/xml/schematron"
 #define LIBXML_STATIC
 #define NOOP ((void)0)
 
@@ -21,9 +41,21 @@ struct xsdParserResult {
     char* errorStr;
 };
 
+struct rngParserResult {
+    xmlRelaxNGPtr rngPtr;
+    char* errorStr;
+};
+
+struct dtdParserResult {
+    xmlDtdPtr dtdPtr;
+    char* errorStr;
+};
+
 struct xmlParserResult {
     xmlDocPtr docPtr;
     char* errorStr;
+    char* encoding;
+    char* columns;
 };
 
 typedef enum {
@@ -40,7 +72,14 @@ struct simpleXmlError {
     char* message;
     int level;
     int line;
+    int column;
+    int domain;
     char* node;
+    char* path;
+    char* rule;
+    char* assertion;
+    char* file;
+    char* columns;
 };
 
 typedef struct _errArray {
@@ -61,10 +100,36 @@ static errArray initErrArray() {
     return errArr;
 }
 
+static void appendErrArray(errArray* errArr, struct simpleXmlError sErr) {
+    if (errArr->len >= errArr->cap) {
+        errArr->cap = errArr->cap * 2;
+        struct simpleXmlError* tmp = calloc(errArr->cap, sizeof(*tmp));
+        memcpy(tmp, errArr->data, errArr->len * sizeof(*tmp));
+        free(errArr->data);
+        errArr->data = tmp;
+    }
+    errArr->data[errArr->len] = sErr;
+    errArr->len++;
+}
+
+static char* copyXmlStr(const xmlChar* str) {
+    if (str == NULL) {
+        return NULL;
+    }
+    char* cpy = malloc(xmlStrlen(str) + 1);
+    strcpy(cpy, (const char*)str);
+    return cpy;
+}
+
 static void freeErrArray(errArray* errArr) {
     for (int i = 0; i < errArr->len; i++) {
         free(errArr->data[i].message);
         free(errArr->data[i].node);
+        free(errArr->data[i].path);
+        free(errArr->data[i].rule);
+        free(errArr->data[i].assertion);
+        free(errArr->data[i].file);
+        free(errArr->data[i].columns);
     }
     free(errArr->data);
 }
@@ -143,6 +208,49 @@ static void genErrorCallback(void* ctx, const char* message, ...) {
     free(newLine);
 }
 
+// Returns the URI of the document a node comes from, for XIncluded nodes the included file,
+// which is found by the XInclude start marker preceding the node or one of its ancestors
+static xmlChar* nodeFile(const xmlNodePtr node) {
+    for (xmlNodePtr cur = node; cur != NULL && cur->type != XML_DOCUMENT_NODE; cur = cur->parent) {
+        int depth = 0;
+        for (xmlNodePtr prev = cur->prev; prev != NULL; prev = prev->prev) {
+            if (prev->type == XML_XINCLUDE_END) {
+                depth++;
+            } else if (prev->type == XML_XINCLUDE_START && depth > 0) {
+                depth--;
+            } else if (prev->type == XML_XINCLUDE_START) {
+                // the marker is the former xi:include element, xmlGetNoNsProp only reads attributes of elements
+                xmlChar* href = NULL;
+                for (xmlAttrPtr attr = prev->properties; attr != NULL; attr = attr->next) {
+                    if (attr->ns == NULL && xmlStrEqual(attr->name, BAD_CAST "href")) {
+                        href = xmlNodeListGetString(prev->doc, attr->children, 1);
+                    }
+                }
+                xmlChar* base = nodeFile(prev);
+                xmlChar* file = href != NULL ? xmlBuildURI(href, base) : NULL;
+                xmlFree(href);
+                xmlFree(base);
+                if (file != NULL) {
+                    return file;
+                }
+            }
+        }
+    }
+    return node->doc != NULL && node->doc->URL != NULL ? xmlStrdup(node->doc->URL) : NULL;
+}
+
+// Marks the copies of ValidLaxForeign validations, the _private pointers of their nodes point to the original nodes
+static int laxCopyMark;
+
+// Returns the node of the validated document for a node of a lax validation copy
+static xmlNodePtr laxOrigNode(xmlNodePtr node) {
+    if (node != NULL && node->doc != NULL && node->doc->_private == &laxCopyMark &&
+        node->_private != NULL) {
+        return node->_private;
+    }
+    return node;
+}
+
 static void simpleStructErrorCallback(
     void* ctx,
 #if LIBXML_VERSION >= 21200
@@ -153,7 +261,7 @@ static void simpleStructErrorCallback(
 ) {
     errArray* sErrArr = ctx;
 
-    struct simpleXmlError sErr;
+    struct simpleXmlError sErr = {0};
     sErr.message = calloc(GO_ERR_INIT, sizeof(char));
     sErr.node = calloc(GO_ERR_INIT, sizeof(char));
 
@@ -161,6 +269,9 @@ static void simpleStructErrorCallback(
     sErr.code = p->code;
     sErr.level = p->level;
     sErr.line = p->line;
+    sErr.column = p->int2;
+    sErr.domain = p->domain;
+    sErr.path = NULL;
 
     int cpyLen = 1 + snprintf(sErr.message, GO_ERR_INIT, "%s", p->message);
     if (cpyLen > GO_ERR_INIT) {
@@ -170,23 +281,359 @@ static void simpleStructErrorCallback(
     }
 
     if (p->node != NULL) {
-        cpyLen = 1 + snprintf(sErr.node, GO_ERR_INIT, "%s",
-                              (((xmlNodePtr)p->node)->name));
+        xmlNodePtr node = laxOrigNode((xmlNodePtr)p->node);
+        if (node != p->node && node->type == XML_ELEMENT_NODE) {
+            sErr.line = xmlGetLineNo(node);
+        }
+        cpyLen = 1 + snprintf(sErr.node, GO_ERR_INIT, "%s", node->name);
         if (cpyLen > GO_ERR_INIT) {
             free(sErr.node);
             sErr.node = malloc(cpyLen);
-            snprintf(sErr.node, cpyLen, "%s", (((xmlNodePtr)p->node)->name));
+            snprintf(sErr.node, cpyLen, "%s", node->name);
+        }
+
+        xmlChar* path = xmlGetNodePath(node);
+        if (path != NULL) {
+            sErr.path = malloc(strlen((const char*)path) + 1);
+            strcpy(sErr.path, (const char*)path);
+            xmlFree(path);
+        }
+
+        xmlChar* file = nodeFile(node);
+        sErr.file = copyXmlStr(file);
+        xmlFree(file);
+    }
+    if (sErr.file == NULL) {
+        sErr.file = copyXmlStr(BAD_CAST p->file);
+    }
+    appendErrArray(sErrArr, sErr);
+}
+
+static void elemNameScanner(void* payload, void* data, const xmlChar* name) {
+    errCtx* ectx = data;
+    xmlSchemaElementPtr elem = payload;
+    if (elem->targetNamespace != NULL) {
+        appendErrCtxErrBuff(ectx, "{");
+        appendErrCtxErrBuff(ectx, (const char*)elem->targetNamespace);
+        appendErrCtxErrBuff(ectx, "}");
+    }
+    appendErrCtxErrBuff(ectx, (const char*)name);
+    appendErrCtxErrBuff(ectx, "\n");
+}
+
+static char* cSchemaGlobalElements(const xmlSchemaPtr schema) {
+    errCtx ectx = initErrCtx(1, GO_ERR_INIT);
+    if (schema != NULL && schema->elemDecl != NULL) {
+        xmlHashScan(schema->elemDecl, elemNameScanner, &ectx);
+    }
+    return ectx.errBuf;
+}
+
+typedef struct _ptrList {
+    void** data;
+    size_t len;
+    size_t cap;
+} ptrList;
+
+// Appends ptr to list
+static void pushPtrList(ptrList* list, void* ptr) {
+    if (list->len >= list->cap) {
+        list->cap = list->cap == 0 ? 4 : list->cap * 2;
+        list->data = realloc(list->data, list->cap * sizeof(void*));
+    }
+    list->data[list->len] = ptr;
+    list->len++;
+}
+
+// Appends ptr to list if it is not in there yet
+static void appendPtrList(ptrList* list, void* ptr) {
+    for (size_t i = 0; i < list->len; i++) {
+        if (list->data[i] == ptr) {
+            return;
+        }
+    }
+    pushPtrList(list, ptr);
+}
+
+static void appendNodeDoc(ptrList* docs, const xmlNodePtr node) {
+    if (node != NULL && node->doc != NULL) {
+        appendPtrList(docs, node->doc);
+    }
+}
+
+static void elemDocScanner(void* payload, void* data, const xmlChar* name) {
+    appendNodeDoc(data, ((xmlSchemaElementPtr)payload)->node);
+}
+
+static void attrDocScanner(void* payload, void* data, const xmlChar* name) {
+    appendNodeDoc(data, ((xmlSchemaAttributePtr)payload)->node);
+}
+
+static void attrGroupDocScanner(void* payload, void* data, const xmlChar* name) {
+    appendNodeDoc(data, ((xmlSchemaAttributeGroupPtr)payload)->node);
+}
+
+static void typeDocScanner(void* payload, void* data, const xmlChar* name) {
+    appendNodeDoc(data, ((xmlSchemaTypePtr)payload)->node);
+}
+
+// The leading fields of libxml2's internal xmlSchemaModelGroupDef, the payload of the groupDecl hash
+typedef struct _schemaModelGroupDef {
+    xmlSchemaTypeType type;
+    xmlSchemaAnnotPtr annot;
+    void* next;
+    void* children;
+    const xmlChar* name;
+    const xmlChar* targetNamespace;
+    xmlNodePtr node;
+} schemaModelGroupDef;
+
+static void groupDocScanner(void* payload, void* data, const xmlChar* name) {
+    if (((schemaModelGroupDef*)payload)->type == XML_SCHEMA_TYPE_GROUP) {
+        appendNodeDoc(data, ((schemaModelGroupDef*)payload)->node);
+    }
+}
+
+// The leading fields of libxml2's internal xmlSchemaImport, the payload of the schemasImports hash
+typedef struct _schemaImport {
+    int type;
+    int flags;
+    const xmlChar* schemaLocation;
+    const xmlChar* origTargetNamespace;
+    const xmlChar* targetNamespace;
+    xmlDocPtr doc;
+} schemaImport;
+
+static void importDocScanner(void* payload, void* data, const xmlChar* name) {
+    if (((schemaImport*)payload)->doc != NULL) {
+        appendPtrList(data, ((schemaImport*)payload)->doc);
+    }
+}
+
+// Appends the values of the enumeration facets of each restriction below node, values are terminated by
+// a unit and sets by a record separator, neither is allowed in xml
+static void scanEnumerationNodes(errCtx* ectx, xmlNodePtr node) {
+    for (; node != NULL; node = node->next) {
+        if (node->type != XML_ELEMENT_NODE) {
+            continue;
+        }
+        bool restriction = node->ns != NULL && xmlStrEqual(node->ns->href, BAD_CAST XSD_NS) &&
+                           xmlStrEqual(node->name, BAD_CAST "restriction");
+        bool found = false;
+        for (xmlNodePtr facet = restriction ? node->children : NULL; facet != NULL; facet = facet->next) {
+            if (facet->type == XML_ELEMENT_NODE && facet->ns != NULL &&
+                xmlStrEqual(facet->ns->href, BAD_CAST XSD_NS) &&
+                xmlStrEqual(facet->name, BAD_CAST "enumeration")) {
+                xmlChar* value = xmlGetProp(facet, BAD_CAST "value");
+                appendErrCtxErrBuff(ectx, value != NULL ? (const char*)value : "");
+                appendErrCtxErrBuff(ectx, "\x1f");
+                xmlFree(value);
+                found = true;
+            }
+        }
+        if (found) {
+            appendErrCtxErrBuff(ectx, "\x1e");
+        }
+        scanEnumerationNodes(ectx, node->children);
+    }
+}
+
+// Collects the value sets of the enumeration facets in the documents of the schema's components,
+// libxml2 does not keep the value sets of anonymous types reachable from the compiled schema.
+static char* cSchemaEnumerations(const xmlSchemaPtr schema) {
+    errCtx ectx = initErrCtx(1, GO_ERR_INIT);
+    ptrList docs = {0};
+
+    if (schema->doc != NULL) {
+        appendPtrList(&docs, schema->doc);
+    }
+    if (schema->elemDecl != NULL) {
+        xmlHashScan(schema->elemDecl, elemDocScanner, &docs);
+    }
+    if (schema->attrDecl != NULL) {
+        xmlHashScan(schema->attrDecl, attrDocScanner, &docs);
+    }
+    if (schema->attrgrpDecl != NULL) {
+        xmlHashScan(schema->attrgrpDecl, attrGroupDocScanner, &docs);
+    }
+    if (schema->typeDecl != NULL) {
+        xmlHashScan(schema->typeDecl, typeDocScanner, &docs);
+    }
+    for (size_t i = 0; i < docs.len; i++) {
+        scanEnumerationNodes(&ectx, xmlDocGetRootElement(docs.data[i]));
+    }
+    free(docs.data);
+    return ectx.errBuf;
+}
+
+// Returns the loaded schema document with the given URL, libxml2 keeps the documents of a compiled schema until it is freed
+static xmlDocPtr findSchemaDoc(const ptrList* docs, const xmlChar* url) {
+    for (size_t i = 0; i < docs->len; i++) {
+        xmlDocPtr doc = docs->data[i];
+        if (doc->URL != NULL && xmlStrEqual(doc->URL, url)) {
+            return doc;
+        }
+    }
+    return NULL;
+}
+
+typedef struct _sensitiveScan {
+    const char* ns;
+    ptrList loaded;
+    ptrList read;
+    ptrList visited;
+    errCtx names;
+} sensitiveScan;
+
+static void scanSensitiveDoc(sensitiveScan* scan, xmlDocPtr doc, const xmlChar* tns, int depth);
+
+// Appends the {namespace}local name of a sensitive element or attribute declaration
+static void appendSensitiveName(sensitiveScan* scan, const xmlNodePtr decl, const xmlChar* tns,
+                                const bool chameleon) {
+    const xmlChar* ns = NULL;
+    xmlChar* local = xmlGetProp(decl, BAD_CAST "name");
+    if (local != NULL) {
+        bool isElem = xmlStrEqual(decl->name, BAD_CAST "element");
+        xmlNodePtr root = xmlDocGetRootElement(decl->doc);
+        xmlChar* form = xmlGetProp(decl, BAD_CAST "form");
+        if (form == NULL) {
+            form = xmlGetProp(root, BAD_CAST(isElem ? "elementFormDefault" : "attributeFormDefault"));
+        }
+        if (decl->parent == root || xmlStrEqual(form, BAD_CAST "qualified")) {
+            ns = tns;
+        }
+        xmlFree(form);
+    } else {
+        xmlChar* ref = xmlGetProp(decl, BAD_CAST "ref");
+        if (ref == NULL) {
+            return;
+        }
+        xmlChar* prefix = NULL;
+        local = xmlSplitQName2(ref, &prefix);
+        if (local == NULL) {
+            local = xmlStrdup(ref);
+        }
+        xmlNsPtr refNs = xmlSearchNs(decl->doc, decl, prefix);
+        // unqualified references of chameleon includes belong to the target namespace of the including schema
+        ns = refNs != NULL ? refNs->href : (chameleon ? tns : NULL);
+        xmlFree(prefix);
+        xmlFree(ref);
+    }
+    if (ns != NULL && *ns != '\0') {
+        appendErrCtxErrBuff(&scan->names, "{");
+        appendErrCtxErrBuff(&scan->names, (const char*)ns);
+        appendErrCtxErrBuff(&scan->names, "}");
+    }
+    appendErrCtxErrBuff(&scan->names, (const char*)local);
+    appendErrCtxErrBuff(&scan->names, "\n");
+    xmlFree(local);
+}
+
+static void scanSensitiveNodes(sensitiveScan* scan, xmlNodePtr node, const xmlChar* tns,
+                               const bool chameleon, int depth) {
+    for (; node != NULL; node = node->next) {
+        if (node->type != XML_ELEMENT_NODE) {
+            continue;
         }
+        if (node->ns != NULL && xmlStrEqual(node->ns->href, BAD_CAST XSD_NS)) {
+            if (xmlStrEqual(node->name, BAD_CAST "element") ||
+                xmlStrEqual(node->name, BAD_CAST "attribute")) {
+                xmlChar* sensitive = xmlGetNsProp(node, BAD_CAST "sensitive", BAD_CAST scan->ns);
+                if (xmlStrEqual(sensitive, BAD_CAST "true") || xmlStrEqual(sensitive, BAD_CAST "1")) {
+                    appendSensitiveName(scan, node, tns, chameleon);
+                }
+                xmlFree(sensitive);
+            } else if (xmlStrEqual(node->name, BAD_CAST "include") ||
+                       xmlStrEqual(node->name, BAD_CAST "import") ||
+                       xmlStrEqual(node->name, BAD_CAST "redefine") ||
+                       xmlStrEqual(node->name, BAD_CAST "override")) {
+                xmlChar* location = xmlGetProp(node, BAD_CAST "schemaLocation");
+                if (location != NULL && depth < MAX_SCHEMA_DEPTH) {
+                    xmlChar* base = xmlNodeGetBase(node->doc, node);
+                    xmlChar* uri = xmlBuildURI(location, base);
+                    xmlFree(base);
+                    xmlDocPtr sub = uri != NULL ? findSchemaDoc(&scan->loaded, uri) : NULL;
+                    if (sub == NULL && uri != NULL) {
+                        sub = findSchemaDoc(&scan->read, uri);
+                    }
+                    if (sub == NULL && uri != NULL) {
+                        // documents without global components are not reachable from the compiled schema
+                        sub = xmlReadFile((const char*)uri, NULL, 0);
+                        if (sub != NULL) {
+                            appendPtrList(&scan->read, sub);
+                        }
+                    }
+                    xmlFree(uri);
+                    // documents that cannot be read are skipped, libxml2 reported or ignored them when parsing the schema
+                    if (sub != NULL) {
+                        scanSensitiveDoc(scan, sub, xmlStrEqual(node->name, BAD_CAST "import") ? NULL : tns,
+                                         depth + 1);
+                    }
+                }
+                xmlFree(location);
+            }
+        }
+        scanSensitiveNodes(scan, node->children, tns, chameleon, depth);
+    }
+}
+
+// Scans a schema document, tns is the target namespace of the including schema or NULL
+static void scanSensitiveDoc(sensitiveScan* scan, xmlDocPtr doc, const xmlChar* tns, int depth) {
+    for (size_t i = 0; i < scan->visited.len; i++) {
+        if (scan->visited.data[i] == doc) {
+            return;
+        }
+    }
+    appendPtrList(&scan->visited, doc);
+
+    xmlNodePtr root = xmlDocGetRootElement(doc);
+    if (root == NULL) {
+        return;
+    }
+    xmlChar* own = xmlGetProp(root, BAD_CAST "targetNamespace");
+    scanSensitiveNodes(scan, root->children, own != NULL ? own : tns, own == NULL && tns != NULL, depth);
+    xmlFree(own);
+}
+
+// Collects the names of the element and attribute declarations marked as sensitive with the ns annotation namespace,
+// libxml2 does not keep foreign attributes of schema components so the schema documents are scanned,
+// documents libxml2 keeps no reference to, like the includes of imported documents, are read again.
+static char* cSensitiveNames(const xmlSchemaPtr schema, const char* ns) {
+    sensitiveScan scan = {0};
+    scan.ns = ns;
+    scan.names = initErrCtx(1, GO_ERR_INIT);
+    xmlSetGenericErrorFunc(NULL, noOutputCallback);
+
+    if (schema->elemDecl != NULL) {
+        xmlHashScan(schema->elemDecl, elemDocScanner, &scan.loaded);
+    }
+    if (schema->attrDecl != NULL) {
+        xmlHashScan(schema->attrDecl, attrDocScanner, &scan.loaded);
+    }
+    if (schema->attrgrpDecl != NULL) {
+        xmlHashScan(schema->attrgrpDecl, attrGroupDocScanner, &scan.loaded);
+    }
+    if (schema->typeDecl != NULL) {
+        xmlHashScan(schema->typeDecl, typeDocScanner, &scan.loaded);
     }
-    if (sErrArr->len >= sErrArr->cap) {
-        sErrArr->cap = sErrArr->cap * 2;
-        struct simpleXmlError* tmp = calloc(sErrArr->cap, sizeof(*tmp));
-        memcpy(tmp, sErrArr->data, sErrArr->len * sizeof(*tmp));
-        free(sErrArr->data);
-        sErrArr->data = tmp;
+    if (schema->groupDecl != NULL) {
+        xmlHashScan(schema->groupDecl, groupDocScanner, &scan.loaded);
     }
-    sErrArr->data[sErrArr->len] = sErr;
-    sErrArr->len++;
+    if (schema->schemasImports != NULL) {
+        xmlHashScan(schema->schemasImports, importDocScanner, &scan.loaded);
+    }
+
+    if (schema->doc != NULL) {
+        scanSensitiveDoc(&scan, schema->doc, NULL, 0);
+    }
+
+    for (size_t i = 0; i < scan.read.len; i++) {
+        xmlFreeDoc(scan.read.data[i]);
+    }
+    free(scan.read.data);
+    free(scan.loaded.data);
+    free(scan.visited.data);
+    return scan.names.errBuf;
 }
 
 static struct xsdParserResult parseSchema(
@@ -249,12 +696,269 @@ static struct xsdParserResult cParseMemSchema(const void* xsd,
     return parseSchema(schemaParserCtxt, options);
 }
 
+static struct rngParserResult parseRng(xmlRelaxNGParserCtxtPtr rngParserCtxt,
+                                       const short int options) {
+    bool err = false;
+    struct rngParserResult parserResult;
+    errCtx ectx = initErrCtx(1, GO_ERR_INIT);
+
+    xmlRelaxNGPtr rng = NULL;
+
+    if (rngParserCtxt == NULL) {
+        err = true;
+        const char msg[] = "Rng parser internal error";
+        appendErrCtxErrBuff(&ectx, msg);
+    } else {
+        if (options & P_ERR_VERBOSE) {
+            xmlSetGenericErrorFunc(&ectx, genErrorCallback);
+        } else {
+            xmlSetGenericErrorFunc(NULL, noOutputCallback);
+        }
+        xmlRelaxNGSetParserErrors(rngParserCtxt, genErrorCallback, noOutputCallback, &ectx);
+
+        rng = xmlRelaxNGParse(rngParserCtxt);
+
+        xmlRelaxNGFreeParserCtxt(rngParserCtxt);
+        if (rng == NULL) {
+            err = true;
+            if (ectx.len <= 1) {
+                const char msg[] = "Malformed rng grammar";
+                appendErrCtxErrBuff(&ectx, msg);
+            }
+        }
+    }
+
+    parserResult.errorStr = malloc(ectx.len);
+    memcpy(parserResult.errorStr, ectx.errBuf, ectx.len);
+    freeErrCtx(ectx);
+    parserResult.rngPtr = rng;
+    errno = err ? -1 : 0;
+    return parserResult;
+}
+
+static struct rngParserResult cParseUrlRng(const char* url, const short int options) {
+    return parseRng(xmlRelaxNGNewParserCtxt(url), options);
+}
+
+static struct rngParserResult cParseMemRng(const void* rng, const int goRngSourceLen,
+                                           const short int options) {
+    return parseRng(xmlRelaxNGNewMemParserCtxt(rng, goRngSourceLen), options);
+}
+
+// Collects parser errors as text and their columns
+typedef struct _parserErrCtx {
+    errCtx* text;
+    errCtx columns;
+} parserErrCtx;
+
+static const char* errorDomainName(const int domain) {
+    switch (domain) {
+    case XML_FROM_PARSER:
+        return "parser ";
+    case XML_FROM_NAMESPACE:
+        return "namespace ";
+    case XML_FROM_DTD:
+    case XML_FROM_VALID:
+        return "validity ";
+    case XML_FROM_XINCLUDE:
+        return "XInclude ";
+    case XML_FROM_IO:
+        return "IO ";
+    default:
+        return "";
+    }
+}
+
+// Appends a parser error as "file:line: domain level : message" line followed by the context lines of verbose parser errors,
+// the column of each error with a line is appended to the columns, the caret of a truncated context line cannot tell it
+static void parserErrorCallback(
+    void* ctx,
+#if LIBXML_VERSION >= 21200
+    const xmlError *p
+#else
+    xmlErrorPtr p
+#endif
+) {
+    parserErrCtx* pctx = ctx;
+    errCtx* ectx = pctx->text;
+    if (p->line > 0) {
+        char line[32];
+        snprintf(line, sizeof(line), "%d: ", p->line);
+        appendErrCtxErrBuff(ectx, p->file != NULL ? p->file : "Entity: line ");
+        appendErrCtxErrBuff(ectx, p->file != NULL ? ":" : "");
+        appendErrCtxErrBuff(ectx, line);
+
+        char column[32];
+        snprintf(column, sizeof(column), "%d ", p->int2);
+        appendErrCtxErrBuff(&pctx->columns, column);
+    }
+    appendErrCtxErrBuff(ectx, errorDomainName(p->domain));
+    appendErrCtxErrBuff(ectx, p->level == XML_ERR_WARNING ? "warning : " : "error : ");
+    appendErrCtxErrBuff(ectx, p->message != NULL ? p->message : "");
+    if (p->message == NULL || p->message[0] == '\0' || p->message[strlen(p->message) - 1] != '\n') {
+        appendErrCtxErrBuff(ectx, "\n");
+    }
+
+    xmlParserCtxtPtr ctxt = p->ctxt;
+    if (p->domain == XML_FROM_PARSER && ctxt != NULL && ctxt->input != NULL) {
+        xmlParserInputPtr input = ctxt->input;
+        if (input->filename == NULL && ctxt->inputNr > 1) {
+            input = ctxt->inputTab[ctxt->inputNr - 2];
+        }
+        // printed through the generic error function, which discards it unless errors are verbose
+        xmlParserPrintFileContext(input);
+    }
+}
+
+// Checks whether a resolved XInclude uri names a file in dir or a directory below it, dir is empty for the working directory.
+// Percent-encoded uris are rejected since libxml2 retries unescaped names of files it cannot open.
+static bool isInDir(const xmlChar* uri, const xmlChar* dir) {
+    size_t dirLen = xmlStrlen(dir);
+    if (uri == NULL || xmlStrncmp(uri, dir, dirLen) != 0) {
+        return false;
+    }
+    const xmlChar* path = uri + dirLen;
+    if (*path == '/' || xmlStrchr(path, ':') != NULL || xmlStrchr(path, '%') != NULL) {
+        return false;
+    }
+    for (const xmlChar* seg = path; seg != NULL; seg = xmlStrchr(seg, '/') != NULL ? xmlStrchr(seg, '/') + 1 : NULL) {
+        if (seg[0] == '.' && seg[1] == '.' && (seg[2] == '/' || seg[2] == '\0')) {
+            return false;
+        }
+    }
+    return true;
+}
+
+// Checks that the XIncludes below node resolve to files in dir, so an untrusted document cannot include other local files,
+// the XIncludes of included documents are not checked. Violations are reported through the parser error callback.
+static bool checkXIncludes(const xmlDocPtr doc, xmlNodePtr node, const xmlChar* dir, parserErrCtx* pctx) {
+    bool ok = true;
+    for (; node != NULL; node = node->next) {
+        if (node->type != XML_ELEMENT_NODE) {
+            continue;
+        }
+        if (node->ns != NULL && xmlStrEqual(node->name, BAD_CAST "include") &&
+            (xmlStrEqual(node->ns->href, XINCLUDE_NS) || xmlStrEqual(node->ns->href, XINCLUDE_OLD_NS))) {
+            xmlChar* href = xmlGetNoNsProp(node, BAD_CAST "href");
+            if (href != NULL && *href != '\0') {
+                xmlChar* base = xmlNodeGetBase(doc, node);
+                xmlChar* uri = xmlBuildURI(href, base);
+                if (!isInDir(uri, dir)) {
+                    errCtx msg = initErrCtx(1, GO_ERR_INIT);
+                    appendErrCtxErrBuff(&msg, "XInclude href '");
+                    appendErrCtxErrBuff(&msg, (const char*)href);
+                    appendErrCtxErrBuff(&msg, "' is outside of the directory of the document\n");
+                    xmlError err = {0};
+                    err.domain = XML_FROM_XINCLUDE;
+                    err.code = XML_XINCLUDE_HREF_URI;
+                    err.level = XML_ERR_FATAL;
+                    err.message = msg.errBuf;
+                    err.file = (char*)doc->URL;
+                    err.line = xmlGetLineNo(node);
+                    parserErrorCallback(pctx, &err);
+                    freeErrCtx(msg);
+                    ok = false;
+                }
+                xmlFree(uri);
+                xmlFree(base);
+            }
+            xmlFree(href);
+        }
+        if (!checkXIncludes(doc, node->children, dir, pctx)) {
+            ok = false;
+        }
+    }
+    return ok;
+}
+
+// Runs the XInclude processing of a parsed doc if requested, frees doc and returns false on failure
+static bool finishDoc(xmlDocPtr* doc, const short int options, parserErrCtx* pctx) {
+    errCtx* ectx = pctx->text;
+    if (*doc == NULL) {
+        if (!(options & P_ERR_VERBOSE)) {
+            const char msg[] = "Malformed xml document";
+            appendErrCtxErrBuff(ectx, msg);
+        }
+        return false;
+    }
+    if (options & P_XINCLUDE) {
+        size_t errLen = ectx->len;
+        const xmlChar* url = (*doc)->URL;
+        const xmlChar* slash = url != NULL ? (const xmlChar*)strrchr((const char*)url, '/') : NULL;
+        xmlChar* dir = slash != NULL ? xmlStrndup(url, slash - url + 1) : xmlStrdup(BAD_CAST "");
+        bool allowed = checkXIncludes(*doc, (*doc)->children, dir, pctx);
+        xmlFree(dir);
+        int res = -1;
+        if (allowed) {
+            xmlSetStructuredErrorFunc(pctx, parserErrorCallback);
+            // xml:base attributes are not added to included elements, schemas rarely allow them
+            res = xmlXIncludeProcessFlags(*doc, XML_PARSE_NOBASEFIX | XML_PARSE_NONET);
+            xmlSetStructuredErrorFunc(NULL, NULL);
+        }
+        if (res < 0) {
+            if (ectx->len == errLen) {
+                const char msg[] = "XInclude processing failed";
+                appendErrCtxErrBuff(ectx, msg);
+            }
+            xmlFreeDoc(*doc);
+            *doc = NULL;
+            return false;
+        }
+    }
+    return true;
+}
+
+// Returns the encoding the parser decoded the document with, UTF-8 if it did not need a decoder
+static char* parsedEncoding(const xmlParserCtxtPtr xmlParserCtxt) {
+    if (xmlParserCtxt->input != NULL && xmlParserCtxt->input->buf != NULL &&
+        xmlParserCtxt->input->buf->encoder != NULL) {
+        return copyXmlStr(BAD_CAST xmlParserCtxt->input->buf->encoder->name);
+    }
+    return copyXmlStr(BAD_CAST "UTF-8");
+}
+
+static struct xmlParserResult cParseUrlDoc(const char* url, const short int options) {
+    struct xmlParserResult parserResult = {0};
+    errCtx ectx = initErrCtx(1, GO_ERR_INIT);
+    parserErrCtx pctx = {.text = &ectx, .columns = initErrCtx(1, GO_ERR_INIT)};
+    xmlDocPtr doc = NULL;
+
+    xmlParserCtxtPtr xmlParserCtxt = xmlNewParserCtxt();
+    if (xmlParserCtxt == NULL) {
+        const char msg[] = "Xml parser internal error";
+        appendErrCtxErrBuff(&ectx, msg);
+    } else {
+        if (options & P_ERR_VERBOSE) {
+            xmlSetGenericErrorFunc(&ectx, genErrorCallback);
+            xmlSetStructuredErrorFunc(&pctx, parserErrorCallback);
+        } else {
+            xmlSetGenericErrorFunc(NULL, noOutputCallback);
+        }
+
+        doc = xmlCtxtReadFile(xmlParserCtxt, url, NULL, 0);
+        xmlSetStructuredErrorFunc(NULL, NULL);
+        parserResult.encoding = parsedEncoding(xmlParserCtxt);
+        xmlFreeParserCtxt(xmlParserCtxt);
+    }
+    bool err = !finishDoc(&doc, options, &pctx);
+
+    parserResult.errorStr = malloc(ectx.len);
+    memcpy(parserResult.errorStr, ectx.errBuf, ectx.len);
+    freeErrCtx(ectx);
+    parserResult.columns = pctx.columns.errBuf;
+    parserResult.docPtr = doc;
+    errno = err ? -1 : 0;
+    return parserResult;
+}
+
 static struct xmlParserResult cParseDoc(const void* goXmlSource,
                                         const int goXmlSourceLen,
+                                        const char* url,
                                         const short int options) {
     bool err = false;
-    struct xmlParserResult parserResult;
+    struct xmlParserResult parserResult = {0};
     errCtx ectx = initErrCtx(1, GO_ERR_INIT);
+    parserErrCtx pctx = {.text = &ectx, .columns = initErrCtx(1, GO_ERR_INIT)};
 
     xmlDocPtr doc = NULL;
     xmlParserCtxtPtr xmlParserCtxt = NULL;
@@ -278,19 +982,18 @@ static struct xmlParserResult cParseDoc(const void* goXmlSource,
         } else {
             if (options & P_ERR_VERBOSE) {
                 xmlSetGenericErrorFunc(&ectx, genErrorCallback);
+                xmlSetStructuredErrorFunc(&pctx, parserErrorCallback);
             } else {
                 xmlSetGenericErrorFunc(NULL, noOutputCallback);
             }
 
-            doc = xmlReadMemory(goXmlSource, goXmlSourceLen, NULL, NULL, 0);
+            doc = xmlCtxtReadMemory(xmlParserCtxt, goXmlSource, goXmlSourceLen, url, NULL, 0);
+            xmlSetStructuredErrorFunc(NULL, NULL);
+            parserResult.encoding = parsedEncoding(xmlParserCtxt);
 
             xmlFreeParserCtxt(xmlParserCtxt);
-            if (doc == NULL) {
+            if (!finishDoc(&doc, options, &pctx)) {
                 err = true;
-                if (!(options & P_ERR_VERBOSE)) {
-                    const char msg[] = "Malformed xml document";
-                    appendErrCtxErrBuff(&ectx, msg);
-                }
             }
         }
     }
@@ -298,71 +1001,1036 @@ static struct xmlParserResult cParseDoc(const void* goXmlSource,
     parserResult.errorStr = malloc(ectx.len);
     memcpy(parserResult.errorStr, ectx.errBuf, ectx.len);
     freeErrCtx(ectx);
+    parserResult.columns = pctx.columns.errBuf;
     parserResult.docPtr = doc;
     errno = err ? -1 : 0;
     return parserResult;
 }
 
-static errArray cValidate(const xmlDocPtr doc, const xmlSchemaPtr schema) {
-    errArray errArr = initErrArray();
-
-    struct simpleXmlError simpleError;
-    simpleError.message = calloc(GO_ERR_INIT, sizeof(char));
-    simpleError.node = calloc(GO_ERR_INIT, sizeof(char));
-
-    if (schema == NULL) {
-        simpleError.type = LIBXML2_ERROR;
-        strcpy(simpleError.message, "Xsd schema null pointer");
-        errArr.data[errArr.len] = simpleError;
-        errArr.len++;
-    } else if (doc == NULL) {
-        simpleError.type = LIBXML2_ERROR;
-        strcpy(simpleError.message, "Xml doc null pointer");
-        errArr.data[errArr.len] = simpleError;
-        errArr.len++;
-    } else {
-        xmlSchemaValidCtxtPtr schemaCtxt;
-        schemaCtxt = xmlSchemaNewValidCtxt(schema);
-
-        if (schemaCtxt == NULL) {
-            simpleError.type = LIBXML2_ERROR;
-            strcpy(simpleError.message, "Xml validation internal error");
-            errArr.data[errArr.len] = simpleError;
-            errArr.len++;
-        } else {
-            xmlSchemaSetValidStructuredErrors(schemaCtxt, simpleStructErrorCallback,
-                                              &errArr);
-            int schemaErr = xmlSchemaValidateDoc(schemaCtxt, doc);
-            xmlSchemaFreeValidCtxt(schemaCtxt);
+static bool isKnownNamespace(const xmlSchemaPtr schema, const xmlChar* ns) {
+    if (ns == NULL || xmlStrEqual(ns, BAD_CAST XSI_NS) ||
+        xmlStrEqual(ns, schema->targetNamespace)) {
+        return true;
+    }
+    return schema->schemasImports != NULL &&
+           xmlHashLookup(schema->schemasImports, ns) != NULL;
+}
 
-            if (schemaErr < 0 && errArr.len == 0) {
-                simpleError.type = LIBXML2_ERROR;
-                strcpy(simpleError.message, "Xml validation internal error");
-                errArr.data[errArr.len] = simpleError;
-                errArr.len++;
-            } else {
-                free(simpleError.node);
-                free(simpleError.message);
+static char* cNodeName(const xmlNodePtr node);
+
+// Sets the _private pointers of the nodes of a copy made by xmlCopyDoc to the original nodes
+static void mapLaxCopy(xmlNodePtr orig, xmlNodePtr copy) {
+    for (; orig != NULL && copy != NULL; orig = orig->next, copy = copy->next) {
+        copy->_private = orig;
+        if (orig->type == XML_ELEMENT_NODE) {
+            xmlAttrPtr origAttr = orig->properties;
+            xmlAttrPtr copyAttr = copy->properties;
+            for (; origAttr != NULL && copyAttr != NULL; origAttr = origAttr->next, copyAttr = copyAttr->next) {
+                copyAttr->_private = origAttr;
             }
+            mapLaxCopy(orig->children, copy->children);
         }
     }
-
-    errno = errArr.len == NO_ERROR ? 0 : -1;
-    return errArr;
 }
 
-static errArray cValidateBuf(const void* goXmlSource,
-                             const int goXmlSourceLen,
+// Adds the default attributes and element values created in a lax validation copy to the original nodes
+static void copyLaxDefaults(xmlNodePtr copy) {
+    for (; copy != NULL; copy = copy->next) {
+        xmlNodePtr orig = copy->_private;
+        if (copy->type != XML_ELEMENT_NODE || orig == NULL) {
+            continue;
+        }
+        for (xmlAttrPtr attr = copy->properties; attr != NULL; attr = attr->next) {
+            if (attr->_private == NULL) {
+                xmlNsPtr ns = NULL;
+                if (attr->ns != NULL) {
+                    ns = xmlSearchNsByHref(orig->doc, orig, attr->ns->href);
+                    if (ns == NULL) {
+                        ns = xmlNewNs(orig, attr->ns->href, attr->ns->prefix);
+                    }
+                }
+                xmlChar* value = xmlNodeListGetString(copy->doc, attr->children, 1);
+                xmlSetNsProp(orig, ns, attr->name, value);
+                xmlFree(value);
+            }
+        }
+        for (xmlNodePtr child = copy->children; child != NULL; child = child->next) {
+            if (child->_private == NULL && child->type == XML_TEXT_NODE) {
+                xmlAddChild(orig, xmlDocCopyNode(child, orig->doc, 1));
+            }
+        }
+        copyLaxDefaults(copy->children);
+    }
+}
+
+typedef struct _laxPass {
+    xmlSchemaPtr schema;
+    ptrList nodes;
+    xmlHashTablePtr skipped;
+    errArray* warnings;
+} laxPass;
+
+// Records a skipped foreign element or attribute of a lax validation copy and adds a warning if requested
+static void skipForeign(laxPass* pass, xmlNodePtr node, const int code) {
+    char key[32];
+    snprintf(key, sizeof(key), "%p", (void*)node);
+    if (xmlHashAddEntry(pass->skipped, BAD_CAST key, node) != 0) {
+        return;
+    }
+    pushPtrList(&pass->nodes, node);
+    if (pass->warnings == NULL) {
+        return;
+    }
+    xmlNodePtr orig = laxOrigNode(node);
+    xmlNodePtr elem = orig->type == XML_ATTRIBUTE_NODE ? orig->parent : orig;
+    char* elemName = cNodeName(elem);
+    char* attrName = orig->type == XML_ATTRIBUTE_NODE ? cNodeName(orig) : NULL;
+
+    errCtx msg = initErrCtx(1, GO_ERR_INIT);
+    appendErrCtxErrBuff(&msg, "Element '");
+    appendErrCtxErrBuff(&msg, elemName);
+    if (attrName != NULL) {
+        appendErrCtxErrBuff(&msg, "', attribute '");
+        appendErrCtxErrBuff(&msg, attrName);
+        appendErrCtxErrBuff(&msg, "': This attribute is skipped, its namespace is not covered by the schema.");
+    } else {
+        appendErrCtxErrBuff(&msg, "': This element is skipped, its namespace is not covered by the schema.");
+    }
+    free(elemName);
+    free(attrName);
+
+    struct simpleXmlError sErr = {0};
+    sErr.type = VALIDATION_ERROR;
+    sErr.code = code;
+    sErr.level = XML_ERR_WARNING;
+    sErr.domain = XML_FROM_SCHEMASV;
+    sErr.line = xmlGetLineNo(elem);
+    sErr.message = msg.errBuf;
+    sErr.node = copyXmlStr(orig->name);
+    xmlChar* path = xmlGetNodePath(orig);
+    sErr.path = copyXmlStr(path);
+    xmlFree(path);
+    xmlChar* file = nodeFile(elem);
+    sErr.file = copyXmlStr(file);
+    xmlFree(file);
+    appendErrArray(pass->warnings, sErr);
+}
+
+// Collects the foreign elements and attributes a validation pass rejected, content matched by wildcards is not reported
+static void laxPassErrorCallback(
+    void* ctx,
+#if LIBXML_VERSION >= 21200
+    const xmlError *p
+#else
+    xmlErrorPtr p
+#endif
+) {
+    laxPass* pass = ctx;
+    xmlNodePtr node = p->node;
+    if (node == NULL || node->type != XML_ELEMENT_NODE) {
+        return;
+    }
+    switch (p->code) {
+    case XML_SCHEMAV_ELEMENT_CONTENT:
+        // the element is not expected at its position, libxml2 does not check the following siblings in this pass,
+        // those of the same foreign namespace directly following it are rejected at the same position
+        if (node->ns != NULL && !isKnownNamespace(pass->schema, node->ns->href)) {
+            for (xmlNodePtr sib = node; sib != NULL; sib = sib->next) {
+                if (sib->type != XML_ELEMENT_NODE) {
+                    continue;
+                }
+                if (sib->ns == NULL || !xmlStrEqual(sib->ns->href, node->ns->href)) {
+                    break;
+                }
+                skipForeign(pass, sib, p->code);
+            }
+        }
+        break;
+    case XML_SCHEMAV_CVC_COMPLEX_TYPE_3_2_1:
+    case XML_SCHEMAV_CVC_COMPLEX_TYPE_3_2_2:
+    case XML_SCHEMAV_CVC_TYPE_3_1_1:
+        // the attribute named by str1 is neither declared nor matched by an attribute wildcard
+        for (xmlAttrPtr attr = node->properties; attr != NULL; attr = attr->next) {
+            if (attr->ns != NULL && !isKnownNamespace(pass->schema, attr->ns->href)) {
+                char* name = cNodeName((xmlNodePtr)attr);
+                if (p->str1 != NULL && strcmp(name, p->str1) == 0) {
+                    skipForeign(pass, (xmlNodePtr)attr, p->code);
+                }
+                free(name);
+            }
+        }
+        break;
+    case XML_SCHEMAV_CVC_TYPE_3_1_2:
+    case XML_SCHEMAV_CVC_COMPLEX_TYPE_2_1:
+        // an element of simple or empty content has child elements
+        for (xmlNodePtr child = node->children; child != NULL; child = child->next) {
+            if (child->type == XML_ELEMENT_NODE && child->ns != NULL &&
+                !isKnownNamespace(pass->schema, child->ns->href)) {
+                skipForeign(pass, child, p->code);
+            }
+        }
+        break;
+    }
+}
+
+// Adds the warning that stripForeign gave up to the errors of a lax validation
+static void laxPassesExceeded(errArray* errArr, xmlDocPtr doc, xmlNodePtr elem) {
+    xmlNodePtr orig = laxOrigNode(elem != NULL ? elem : xmlDocGetRootElement(doc));
+    if (orig == NULL) {
+        return;
+    }
+    char msg[160];
+    snprintf(msg, sizeof(msg),
+             "Foreign content is no longer skipped after %d validation passes, the remaining foreign content is validated.",
+             LAX_MAX_PASSES);
+    struct simpleXmlError sErr = {0};
+    sErr.type = VALIDATION_ERROR;
+    sErr.code = XML_SCHEMAV_MISC;
+    sErr.level = XML_ERR_WARNING;
+    sErr.domain = XML_FROM_SCHEMASV;
+    sErr.line = xmlGetLineNo(orig);
+    sErr.message = copyXmlStr(BAD_CAST msg);
+    sErr.node = copyXmlStr(orig->name);
+    xmlChar* path = xmlGetNodePath(orig);
+    sErr.path = copyXmlStr(path);
+    xmlFree(path);
+    xmlChar* file = nodeFile(orig);
+    sErr.file = copyXmlStr(file);
+    xmlFree(file);
+    appendErrArray(errArr, sErr);
+}
+
+// Removes the foreign elements and attributes of a lax validation copy that the schema rejects,
+// validation is repeated since libxml2 stops checking the content of an element after the first unexpected child.
+// After LAX_MAX_PASSES passes the remaining foreign content is left to the validation and a warning is added to errArr,
+// the skipped nodes are only reported if warn is set.
+static bool stripForeign(const xmlSchemaPtr schema, xmlDocPtr doc, xmlNodePtr elem, errArray* errArr,
+                         const bool warn) {
+    for (int passes = 0;; passes++) {
+        if (passes == LAX_MAX_PASSES) {
+            laxPassesExceeded(errArr, doc, elem);
+            return true;
+        }
+        laxPass pass = {.schema = schema, .warnings = warn ? errArr : NULL};
+        xmlSchemaValidCtxtPtr schemaCtxt = xmlSchemaNewValidCtxt(schema);
+        if (schemaCtxt == NULL) {
+            return false;
+        }
+        pass.skipped = xmlHashCreate(0);
+        xmlSchemaSetValidStructuredErrors(schemaCtxt, laxPassErrorCallback, &pass);
+        if (elem != NULL) {
+            xmlSchemaValidateOneElement(schemaCtxt, elem);
+        } else {
+            xmlSchemaValidateDoc(schemaCtxt, doc);
+        }
+        xmlSchemaFreeValidCtxt(schemaCtxt);
+
+        size_t skipped = pass.nodes.len;
+        for (size_t i = 0; i < pass.nodes.len; i++) {
+            xmlNodePtr node = pass.nodes.data[i];
+            if (node->type == XML_ATTRIBUTE_NODE) {
+                xmlRemoveProp((xmlAttrPtr)node);
+            } else {
+                xmlUnlinkNode(node);
+                xmlFreeNode(node);
+            }
+        }
+        free(pass.nodes.data);
+        xmlHashFree(pass.skipped, NULL);
+        if (skipped == 0) {
+            return true;
+        }
+    }
+}
+
+static xmlNodePtr correspondingNode(xmlNodePtr orig, xmlNodePtr copy,
+                                    const xmlNodePtr target) {
+    for (; orig != NULL && copy != NULL; orig = orig->next, copy = copy->next) {
+        if (orig == target) {
+            return copy;
+        }
+        if (orig->type == XML_ELEMENT_NODE) {
+            xmlNodePtr found = correspondingNode(orig->children, copy->children, target);
+            if (found != NULL) {
+                return found;
+            }
+        }
+    }
+    return NULL;
+}
+
+static errArray cValidate(const xmlDocPtr doc, const xmlSchemaPtr schema,
+                          const xmlNodePtr elem, const short int options) {
+    errArray errArr = initErrArray();
+
+    struct simpleXmlError simpleError = {0};
+    simpleError.message = calloc(GO_ERR_INIT, sizeof(char));
+    simpleError.node = calloc(GO_ERR_INIT, sizeof(char));
+
+    if (schema == NULL) {
+        simpleError.type = LIBXML2_ERROR;
+        strcpy(simpleError.message, "Xsd schema null pointer");
+        errArr.data[errArr.len] = simpleError;
+        errArr.len++;
+    } else if (doc == NULL) {
+        simpleError.type = LIBXML2_ERROR;
+        strcpy(simpleError.message, "Xml doc null pointer");
+        errArr.data[errArr.len] = simpleError;
+        errArr.len++;
+    } else {
+        xmlSchemaValidCtxtPtr schemaCtxt;
+        schemaCtxt = xmlSchemaNewValidCtxt(schema);
+
+        if (schemaCtxt == NULL) {
+            simpleError.type = LIBXML2_ERROR;
+            strcpy(simpleError.message, "Xml validation internal error");
+            errArr.data[errArr.len] = simpleError;
+            errArr.len++;
+        } else {
+            xmlSchemaSetValidStructuredErrors(schemaCtxt, simpleStructErrorCallback,
+                                              &errArr);
+            if (options & V_ADD_DEFAULTS) {
+                xmlSchemaSetValidOptions(schemaCtxt, XML_SCHEMA_VAL_VC_I_CREATE);
+            }
+
+            xmlDocPtr valDoc = doc;
+            xmlNodePtr valElem = elem;
+            int schemaErr = 0;
+            if (options & V_LAX_FOREIGN) {
+                // the foreign content is removed from a copy, errors are reported for the original nodes
+                valDoc = xmlCopyDoc(doc, 1);
+                if (valDoc == NULL) {
+                    schemaErr = -1;
+                } else {
+                    valDoc->_private = &laxCopyMark;
+                    mapLaxCopy(doc->children, valDoc->children);
+                    if (elem != NULL) {
+                        valElem = correspondingNode(doc->children, valDoc->children, elem);
+                    }
+                    if (!stripForeign(schema, valDoc, valElem, &errArr, options & V_WARN_FOREIGN)) {
+                        schemaErr = -1;
+                    }
+                }
+            }
+
+            if (schemaErr == 0) {
+                schemaErr = valElem != NULL ? xmlSchemaValidateOneElement(schemaCtxt, valElem)
+                                            : xmlSchemaValidateDoc(schemaCtxt, valDoc);
+            }
+            xmlSchemaFreeValidCtxt(schemaCtxt);
+            if (valDoc != doc && valDoc != NULL) {
+                if (options & V_ADD_DEFAULTS) {
+                    copyLaxDefaults(valDoc->children);
+                }
+                xmlFreeDoc(valDoc);
+            }
+
+            if (schemaErr < 0 && errArr.len == 0) {
+                simpleError.type = LIBXML2_ERROR;
+                strcpy(simpleError.message, "Xml validation internal error");
+                errArr.data[errArr.len] = simpleError;
+                errArr.len++;
+            } else {
+                free(simpleError.node);
+                free(simpleError.message);
+            }
+        }
+    }
+
+    errno = errArr.len == NO_ERROR ? 0 : -1;
+    return errArr;
+}
+
+static errArray cValidateRng(const xmlDocPtr doc, const xmlRelaxNGPtr rng) {
+    errArray errArr = initErrArray();
+
+    struct simpleXmlError simpleError = {0};
+    simpleError.message = calloc(GO_ERR_INIT, sizeof(char));
+    simpleError.node = calloc(GO_ERR_INIT, sizeof(char));
+
+    xmlRelaxNGValidCtxtPtr rngCtxt = xmlRelaxNGNewValidCtxt(rng);
+    if (rngCtxt == NULL) {
+        simpleError.type = LIBXML2_ERROR;
+        strcpy(simpleError.message, "Xml validation internal error");
+        errArr.data[errArr.len] = simpleError;
+        errArr.len++;
+    } else {
+        xmlRelaxNGSetValidStructuredErrors(rngCtxt, simpleStructErrorCallback, &errArr);
+        int rngErr = xmlRelaxNGValidateDoc(rngCtxt, doc);
+        xmlRelaxNGFreeValidCtxt(rngCtxt);
+
+        if (rngErr != 0 && errArr.len == 0) {
+            simpleError.type = LIBXML2_ERROR;
+            strcpy(simpleError.message, "Xml validation internal error");
+            errArr.data[errArr.len] = simpleError;
+            errArr.len++;
+        } else {
+            free(simpleError.node);
+            free(simpleError.message);
+        }
+    }
+
+    errno = errArr.len == NO_ERROR ? 0 : -1;
+    return errArr;
+}
+
+static bool isSctElement(const xmlNodePtr node, const char* name) {
+    return node->type == XML_ELEMENT_NODE && node->ns != NULL &&
+           (xmlStrEqual(node->ns->href, BAD_CAST SCT_NS) ||
+            xmlStrEqual(node->ns->href, BAD_CAST SCT_OLD_NS)) &&
+           xmlStrEqual(node->name, BAD_CAST name);
+}
+
+// Turns a rule context pattern like "order|item[@id]" into the expression
+// "//order|//item[@id]" selecting every node the pattern matches.
+static xmlChar* sctContextExpr(const xmlChar* context) {
+    xmlChar* expr = NULL;
+    xmlChar quote = 0;
+    int depth = 0;
+    const xmlChar* start = context;
+
+    for (const xmlChar* cur = context;; cur++) {
+        if (quote != 0 && *cur != '\0') {
+            if (*cur == quote) {
+                quote = 0;
+            }
+        } else if (*cur == '"' || *cur == '\'') {
+            quote = *cur;
+        } else if (*cur == '[' || *cur == '(') {
+            depth++;
+        } else if (*cur == ']' || *cur == ')') {
+            depth--;
+        } else if (*cur == '\0' || (*cur == '|' && depth == 0)) {
+            while (start < cur && xmlIsBlank_ch(*start)) {
+                start++;
+            }
+            if (expr != NULL) {
+                expr = xmlStrcat(expr, BAD_CAST "|");
+            }
+            if (*start != '/') {
+                expr = xmlStrcat(expr, BAD_CAST "//");
+            }
+            expr = xmlStrncat(expr, start, cur - start);
+            if (*cur == '\0') {
+                break;
+            }
+            start = cur + 1;
+        }
+    }
+    return expr;
+}
+
+static void sctParserError(const xmlNodePtr node, const char* msg,
+                           const xmlChar* expr, errCtx* ectx) {
+    char line[GO_ERR_INIT];
+    if (node->doc->URL != NULL) {
+        snprintf(line, GO_ERR_INIT, "%s:%ld: schematron error : %s '%s'\n",
+                 (const char*)node->doc->URL, xmlGetLineNo(node), msg,
+                 expr != NULL ? (const char*)expr : "");
+    } else {
+        snprintf(line, GO_ERR_INIT, "Entity: line %ld: schematron error : %s '%s'\n",
+                 xmlGetLineNo(node), msg, expr != NULL ? (const char*)expr : "");
+    }
+    appendErrCtxErrBuff(ectx, line);
+}
+
+static void sctCheckExpr(const xmlNodePtr node, const char* attr, bool required,
+                         errCtx* ectx) {
+    xmlChar* expr = xmlGetNoNsProp(node, BAD_CAST attr);
+    if (expr == NULL) {
+        if (required) {
+            sctParserError(node, "Missing attribute", BAD_CAST attr, ectx);
+        }
+        return;
+    }
+    if (xmlStrEqual(BAD_CAST attr, BAD_CAST "context")) {
+        xmlChar* context = expr;
+        expr = sctContextExpr(context);
+        xmlFree(context);
+    }
+    xmlXPathCompExprPtr comp = xmlXPathCompile(expr);
+    if (comp == NULL) {
+        sctParserError(node, "Invalid expression", expr, ectx);
+    } else {
+        xmlXPathFreeCompExpr(comp);
+    }
+    xmlFree(expr);
+}
+
+static bool isSctAbstract(const xmlNodePtr node) {
+    xmlChar* abstract = xmlGetNoNsProp(node, BAD_CAST "abstract");
+    bool res = xmlStrEqual(abstract, BAD_CAST "true");
+    xmlFree(abstract);
+    return res;
+}
+
+// Finds the abstract rule with the id below node
+static xmlNodePtr sctFindAbstract(xmlNodePtr node, const xmlChar* id) {
+    for (; node != NULL; node = node->next) {
+        if (isSctElement(node, "rule") && isSctAbstract(node)) {
+            xmlChar* ruleId = xmlGetNoNsProp(node, BAD_CAST "id");
+            bool found = xmlStrEqual(ruleId, id);
+            xmlFree(ruleId);
+            if (found) {
+                return node;
+            }
+        } else if (node->type == XML_ELEMENT_NODE) {
+            xmlNodePtr rule = sctFindAbstract(node->children, id);
+            if (rule != NULL) {
+                return rule;
+            }
+        }
+    }
+    return NULL;
+}
+
+// Returns the abstract rule an extends element refers to
+static xmlNodePtr sctExtended(const xmlNodePtr root, const xmlNodePtr extends) {
+    xmlChar* id = xmlGetNoNsProp(extends, BAD_CAST "rule");
+    xmlNodePtr rule = id != NULL ? sctFindAbstract(root->children, id) : NULL;
+    xmlFree(id);
+    return rule;
+}
+
+#define SCT_MAX_EXTENDS 32
+
+// Returns true if rule extends one of the rules on the stack, directly or through other abstract rules
+static bool sctExtendsCycle(const xmlNodePtr root, const xmlNodePtr rule,
+                            xmlNodePtr* stack, int depth) {
+    if (depth == SCT_MAX_EXTENDS) {
+        return true;
+    }
+    for (int i = 0; i < depth; i++) {
+        if (stack[i] == rule) {
+            return true;
+        }
+    }
+    stack[depth] = rule;
+    for (xmlNodePtr child = rule->children; child != NULL; child = child->next) {
+        if (isSctElement(child, "extends")) {
+            xmlNodePtr extended = sctExtended(root, child);
+            if (extended != NULL && sctExtendsCycle(root, extended, stack, depth + 1)) {
+                return true;
+            }
+        }
+    }
+    return false;
+}
+
+static void sctCheckNodes(const xmlNodePtr root, xmlNodePtr node, errCtx* ectx) {
+    for (; node != NULL; node = node->next) {
+        if (isSctElement(node, "phase") || isSctElement(node, "include") ||
+            isSctElement(node, "diagnostics") || isSctElement(node, "param")) {
+            sctParserError(node, "Unsupported element", node->name, ectx);
+            continue;
+        } else if (isSctElement(node, "pattern") &&
+                   (isSctAbstract(node) || xmlHasProp(node, BAD_CAST "is-a") != NULL)) {
+            sctParserError(node, "Unsupported abstract", node->name, ectx);
+            continue;
+        } else if (isSctElement(node, "rule")) {
+            if (isSctAbstract(node)) {
+                if (xmlHasProp(node, BAD_CAST "id") == NULL) {
+                    sctParserError(node, "Missing attribute", BAD_CAST "id", ectx);
+                }
+            } else {
+                sctCheckExpr(node, "context", true, ectx);
+            }
+        } else if (isSctElement(node, "extends")) {
+            xmlChar* id = xmlGetNoNsProp(node, BAD_CAST "rule");
+            xmlNodePtr stack[SCT_MAX_EXTENDS];
+            xmlNodePtr extended = sctExtended(root, node);
+            if (id == NULL) {
+                sctParserError(node, "Missing attribute", BAD_CAST "rule", ectx);
+            } else if (extended == NULL) {
+                sctParserError(node, "Unknown abstract rule", id, ectx);
+            } else if (sctExtendsCycle(root, extended, stack, 0)) {
+                sctParserError(node, "Recursive abstract rule", id, ectx);
+            }
+            xmlFree(id);
+        } else if (isSctElement(node, "let")) {
+            if (xmlHasProp(node, BAD_CAST "name") == NULL) {
+                sctParserError(node, "Missing attribute", BAD_CAST "name", ectx);
+            }
+            sctCheckExpr(node, "value", true, ectx);
+        } else if (isSctElement(node, "assert") || isSctElement(node, "report")) {
+            sctCheckExpr(node, "test", true, ectx);
+        } else if (isSctElement(node, "value-of")) {
+            sctCheckExpr(node, "select", true, ectx);
+        } else if (isSctElement(node, "name")) {
+            sctCheckExpr(node, "path", false, ectx);
+        }
+        sctCheckNodes(root, node->children, ectx);
+    }
+}
+
+static struct xmlParserResult parseSct(xmlDocPtr doc, const short int options,
+                                       errCtx ectx) {
+    bool err = false;
+    struct xmlParserResult parserResult;
+
+    if (doc == NULL) {
+        err = true;
+        if (!(options & P_ERR_VERBOSE)) {
+            const char msg[] = "Malformed schematron schema";
+            appendErrCtxErrBuff(&ectx, msg);
+        }
+    } else {
+        xmlNodePtr root = xmlDocGetRootElement(doc);
+        if (root == NULL || !isSctElement(root, "schema")) {
+            const char msg[] = "Root element is not a schematron schema";
+            appendErrCtxErrBuff(&ectx, msg);
+        } else {
+            xmlSetGenericErrorFunc(NULL, noOutputCallback);
+            sctCheckNodes(root, root->children, &ectx);
+        }
+        if (ectx.len > 1) {
+            err = true;
+            xmlFreeDoc(doc);
+            doc = NULL;
+        }
+    }
+
+    parserResult.errorStr = malloc(ectx.len);
+    memcpy(parserResult.errorStr, ectx.errBuf, ectx.len);
+    freeErrCtx(ectx);
+    parserResult.docPtr = doc;
+    errno = err ? -1 : 0;
+    return parserResult;
+}
+
+static struct xmlParserResult cParseUrlSct(const char* url, const short int options) {
+    errCtx ectx = initErrCtx(1, GO_ERR_INIT);
+    if (options & P_ERR_VERBOSE) {
+        xmlSetGenericErrorFunc(&ectx, genErrorCallback);
+    } else {
+        xmlSetGenericErrorFunc(NULL, noOutputCallback);
+    }
+    xmlDocPtr doc = xmlReadFile(url, NULL, 0);
+    return parseSct(doc, options, ectx);
+}
+
+static struct xmlParserResult cParseMemSct(const void* sct, const int goSctSourceLen,
+                                           const short int options) {
+    errCtx ectx = initErrCtx(1, GO_ERR_INIT);
+    if (options & P_ERR_VERBOSE) {
+        xmlSetGenericErrorFunc(&ectx, genErrorCallback);
+    } else {
+        xmlSetGenericErrorFunc(NULL, noOutputCallback);
+    }
+    xmlDocPtr doc = xmlReadMemory(sct, goSctSourceLen, NULL, NULL, 0);
+    return parseSct(doc, options, ectx);
+}
+
+static xmlXPathObjectPtr sctEval(xmlXPathContextPtr xpathCtxt, xmlNodePtr node,
+                                 const xmlChar* expr) {
+    xpathCtxt->node = node;
+    xpathCtxt->contextSize = 1;
+    xpathCtxt->proximityPosition = 1;
+    return xmlXPathEvalExpression(expr, xpathCtxt);
+}
+
+// Collapses whitespace runs of an assertion text into single spaces
+static char* sctNormalize(const xmlChar* str) {
+    size_t n = 0;
+    bool space = false;
+    char* out = malloc(xmlStrlen(str) + 1);
+
+    for (; str != NULL && *str != '\0'; str++) {
+        if (xmlIsBlank_ch(*str)) {
+            space = n > 0;
+        } else {
+            if (space) {
+                out[n++] = ' ';
+            }
+            space = false;
+            out[n++] = *str;
+        }
+    }
+    out[n] = '\0';
+    return out;
+}
+
+// Builds the message of a failed assert or successful report, expanding name and value-of,
+// the document values of value-of are masked if redact is set
+static char* sctMessage(xmlXPathContextPtr xpathCtxt, const xmlNodePtr test,
+                        xmlNodePtr node, const bool redact) {
+    xmlChar* msg = NULL;
+
+    for (xmlNodePtr child = test->children; child != NULL; child = child->next) {
+        if (child->type == XML_TEXT_NODE || child->type == XML_CDATA_SECTION_NODE) {
+            msg = xmlStrcat(msg, child->content);
+        } else if (isSctElement(child, "name") || isSctElement(child, "value-of")) {
+            xmlChar* expr = NULL;
+            if (isSctElement(child, "value-of")) {
+                expr = xmlGetNoNsProp(child, BAD_CAST "select");
+            } else {
+                xmlChar* path = xmlGetNoNsProp(child, BAD_CAST "path");
+                expr = xmlStrcat(xmlStrdup(BAD_CAST "name("), path != NULL ? path : BAD_CAST ".");
+                expr = xmlStrcat(expr, BAD_CAST ")");
+                xmlFree(path);
+            }
+            xmlXPathObjectPtr obj = sctEval(xpathCtxt, node, expr);
+            if (obj != NULL && redact && isSctElement(child, "value-of")) {
+                msg = xmlStrcat(msg, BAD_CAST "***");
+                xmlXPathFreeObject(obj);
+            } else if (obj != NULL) {
+                xmlChar* str = xmlXPathCastToString(obj);
+                msg = xmlStrcat(msg, str);
+                xmlFree(str);
+                xmlXPathFreeObject(obj);
+            }
+            xmlFree(expr);
+        } else if (child->type == XML_ELEMENT_NODE) {
+            xmlChar* str = xmlNodeGetContent(child);
+            msg = xmlStrcat(msg, str);
+            xmlFree(str);
+        }
+    }
+
+    char* normalized = sctNormalize(msg);
+    xmlFree(msg);
+    return normalized;
+}
+
+// Binds the value of a let element evaluated for node to its name,
+// the name and the shadowed value are appended to saved unless it is NULL
+static void sctBindLet(xmlXPathContextPtr xpathCtxt, const xmlNodePtr let, xmlNodePtr node,
+                       ptrList* saved) {
+    xmlChar* name = xmlGetNoNsProp(let, BAD_CAST "name");
+    xmlChar* expr = xmlGetNoNsProp(let, BAD_CAST "value");
+    if (saved != NULL) {
+        pushPtrList(saved, xmlStrdup(name));
+        pushPtrList(saved, xmlXPathVariableLookup(xpathCtxt, name));
+    }
+    // an unevaluable value leaves the variable unbound, tests using it fail
+    xmlXPathRegisterVariable(xpathCtxt, name, sctEval(xpathCtxt, node, expr));
+    xmlFree(expr);
+    xmlFree(name);
+}
+
+// Restores the variables shadowed by the lets bound with saved in reverse order and empties saved,
+// variables that were unbound before are removed
+static void sctRestoreLets(xmlXPathContextPtr xpathCtxt, ptrList* saved) {
+    while (saved->len >= 2) {
+        xmlXPathObjectPtr value = saved->data[--saved->len];
+        xmlChar* name = saved->data[--saved->len];
+        xmlXPathRegisterVariable(xpathCtxt, name, value);
+        xmlFree(name);
+    }
+}
+
+// Checks whether two siblings get the same step in a path
+static bool sctSameStep(const xmlNodePtr a, const xmlNodePtr b) {
+    if (a->type != b->type) {
+        return (a->type == XML_TEXT_NODE || a->type == XML_CDATA_SECTION_NODE) &&
+               (b->type == XML_TEXT_NODE || b->type == XML_CDATA_SECTION_NODE);
+    }
+    if (a->type != XML_ELEMENT_NODE) {
+        return true;
+    }
+    return xmlStrEqual(a->name, b->name) &&
+           xmlStrEqual(a->ns != NULL ? a->ns->href : NULL, b->ns != NULL ? b->ns->href : NULL);
+}
+
+// Builds the path of a node from the names used in the document like "/orders/order[2]/@id",
+// unlike xmlGetNodePath elements of a default namespace are named
+static xmlChar* sctNodePath(xmlNodePtr node) {
+    xmlChar* path = NULL;
+
+    for (; node != NULL && node->type != XML_DOCUMENT_NODE; node = node->parent) {
+        xmlChar* step = xmlStrdup(BAD_CAST "/");
+        if (node->type == XML_ATTRIBUTE_NODE || node->type == XML_ELEMENT_NODE) {
+            if (node->type == XML_ATTRIBUTE_NODE) {
+                step = xmlStrcat(step, BAD_CAST "@");
+            }
+            if (node->ns != NULL && node->ns->prefix != NULL) {
+                step = xmlStrcat(step, node->ns->prefix);
+                step = xmlStrcat(step, BAD_CAST ":");
+            }
+            step = xmlStrcat(step, node->name);
+        } else if (node->type == XML_TEXT_NODE || node->type == XML_CDATA_SECTION_NODE) {
+            step = xmlStrcat(step, BAD_CAST "text()");
+        } else if (node->type == XML_COMMENT_NODE) {
+            step = xmlStrcat(step, BAD_CAST "comment()");
+        } else if (node->type == XML_PI_NODE) {
+            step = xmlStrcat(step, BAD_CAST "processing-instruction()");
+        }
+        if (node->type != XML_ATTRIBUTE_NODE) {
+            int pos = 1, count = 1;
+            for (xmlNodePtr sib = node->prev; sib != NULL; sib = sib->prev) {
+                if (sctSameStep(node, sib)) {
+                    pos++;
+                    count++;
+                }
+            }
+            for (xmlNodePtr sib = node->next; sib != NULL; sib = sib->next) {
+                if (sctSameStep(node, sib)) {
+                    count++;
+                }
+            }
+            if (count > 1) {
+                char index[16];
+                snprintf(index, sizeof(index), "[%d]", pos);
+                step = xmlStrcat(step, BAD_CAST index);
+            }
+        }
+        step = xmlStrcat(step, path);
+        xmlFree(path);
+        path = step;
+    }
+    return path != NULL ? path : xmlStrdup(BAD_CAST "/");
+}
+
+// Runs the lets, asserts and reports of a rule and the abstract rules it extends in document order
+static void sctRunRule(errArray* errArr, xmlXPathContextPtr xpathCtxt, const xmlNodePtr root,
+                       const xmlNodePtr rule, const xmlChar* context, xmlNodePtr node,
+                       ptrList* saved, const bool redact) {
+    for (xmlNodePtr test = rule->children; test != NULL; test = test->next) {
+        if (isSctElement(test, "let")) {
+            sctBindLet(xpathCtxt, test, node, saved);
+            continue;
+        }
+        if (isSctElement(test, "extends")) {
+            sctRunRule(errArr, xpathCtxt, root, sctExtended(root, test), context, node, saved, redact);
+            continue;
+        }
+        bool report = isSctElement(test, "report");
+        if (!report && !isSctElement(test, "assert")) {
+            continue;
+        }
+
+        xmlChar* expr = xmlGetNoNsProp(test, BAD_CAST "test");
+        xmlXPathObjectPtr obj = sctEval(xpathCtxt, node, expr);
+        bool fired = obj == NULL || xmlXPathCastToBoolean(obj) == report;
+        xmlXPathFreeObject(obj);
+
+        if (fired) {
+            xmlNodePtr elem = node->type == XML_ATTRIBUTE_NODE ? node->parent : node;
+            xmlChar* id = xmlGetNoNsProp(test, BAD_CAST "id");
+            xmlChar* path = sctNodePath(node);
+
+            struct simpleXmlError sErr = {0};
+            sErr.type = VALIDATION_ERROR;
+            sErr.code = report ? XML_SCHEMATRONV_REPORT : XML_SCHEMATRONV_ASSERT;
+            sErr.level = XML_ERR_ERROR;
+            sErr.line = elem->type == XML_ELEMENT_NODE ? xmlGetLineNo(elem) : 0;
+            sErr.domain = XML_FROM_SCHEMATRONV;
+            sErr.node = node->name != NULL ? copyXmlStr(node->name) : calloc(1, sizeof(char));
+            sErr.path = copyXmlStr(path);
+            sErr.rule = copyXmlStr(context);
+            sErr.assertion = copyXmlStr(id);
+            if (obj == NULL) {
+                sErr.message = copyXmlStr(BAD_CAST "Failed to evaluate the test expression");
+            } else {
+                sErr.message = sctMessage(xpathCtxt, test, node, redact);
+            }
+            appendErrArray(errArr, sErr);
+
+            xmlFree(path);
+            xmlFree(id);
+        }
+        xmlFree(expr);
+    }
+}
+
+static errArray cValidateSct(const xmlDocPtr doc, const xmlDocPtr sct, const bool redact) {
+    errArray errArr = initErrArray();
+
+    xmlSetGenericErrorFunc(NULL, noOutputCallback);
+    xmlXPathContextPtr xpathCtxt = xmlXPathNewContext(doc);
+    if (xpathCtxt == NULL) {
+        struct simpleXmlError simpleError = {0};
+        simpleError.type = LIBXML2_ERROR;
+        simpleError.message = copyXmlStr(BAD_CAST "Xml validation internal error");
+        appendErrArray(&errArr, simpleError);
+        errno = -1;
+        return errArr;
+    }
+
+    xmlNodePtr root = xmlDocGetRootElement(sct);
+    for (xmlNodePtr ns = root->children; ns != NULL; ns = ns->next) {
+        if (isSctElement(ns, "ns")) {
+            xmlChar* prefix = xmlGetNoNsProp(ns, BAD_CAST "prefix");
+            xmlChar* uri = xmlGetNoNsProp(ns, BAD_CAST "uri");
+            if (prefix != NULL && uri != NULL) {
+                xmlXPathRegisterNs(xpathCtxt, prefix, uri);
+            }
+            xmlFree(prefix);
+            xmlFree(uri);
+        }
+    }
+
+    for (xmlNodePtr let = root->children; let != NULL; let = let->next) {
+        if (isSctElement(let, "let")) {
+            sctBindLet(xpathCtxt, let, (xmlNodePtr)doc, NULL);
+        }
+    }
+
+    ptrList patternSaved = {0};
+    ptrList ruleSaved = {0};
+    for (xmlNodePtr pattern = root->children; pattern != NULL; pattern = pattern->next) {
+        if (!isSctElement(pattern, "pattern")) {
+            continue;
+        }
+        for (xmlNodePtr let = pattern->children; let != NULL; let = let->next) {
+            if (isSctElement(let, "let")) {
+                sctBindLet(xpathCtxt, let, (xmlNodePtr)doc, &patternSaved);
+            }
+        }
+        // Within a pattern a node is only checked by the first rule matching it, nodes are keyed by their address
+        xmlHashTablePtr checked = xmlHashCreate(0);
+        for (xmlNodePtr rule = pattern->children; rule != NULL; rule = rule->next) {
+            if (!isSctElement(rule, "rule") || isSctAbstract(rule)) {
+                continue;
+            }
+            xmlChar* context = xmlGetNoNsProp(rule, BAD_CAST "context");
+            xmlChar* expr = sctContextExpr(context);
+            xmlXPathObjectPtr matched = sctEval(xpathCtxt, (xmlNodePtr)doc, expr);
+            if (matched != NULL && matched->nodesetval != NULL) {
+                for (int i = 0; i < matched->nodesetval->nodeNr; i++) {
+                    xmlNodePtr node = matched->nodesetval->nodeTab[i];
+                    char key[32];
+                    snprintf(key, sizeof(key), "%p", (void*)node);
+                    if (node->type == XML_NAMESPACE_DECL ||
+                        xmlHashAddEntry(checked, BAD_CAST key, node) != 0) {
+                        continue;
+                    }
+                    sctRunRule(&errArr, xpathCtxt, root, rule, context, node, &ruleSaved, redact);
+                    sctRestoreLets(xpathCtxt, &ruleSaved);
+                }
+            }
+            xmlXPathFreeObject(matched);
+            xmlFree(expr);
+            xmlFree(context);
+        }
+        xmlHashFree(checked, NULL);
+        sctRestoreLets(xpathCtxt, &patternSaved);
+    }
+    free(patternSaved.data);
+    free(ruleSaved.data);
+    xmlXPathFreeContext(xpathCtxt);
+
+    errno = errArr.len == NO_ERROR ? 0 : -1;
+    return errArr;
+}
+
+static struct dtdParserResult parseDtd(xmlDtdPtr dtd, const short int options,
+                                       errCtx ectx) {
+    struct dtdParserResult parserResult;
+
+    if (dtd == NULL && (ectx.len <= 1 || !(options & P_ERR_VERBOSE))) {
+        const char msg[] = "Malformed dtd";
+        appendErrCtxErrBuff(&ectx, msg);
+    }
+
+    parserResult.errorStr = malloc(ectx.len);
+    memcpy(parserResult.errorStr, ectx.errBuf, ectx.len);
+    freeErrCtx(ectx);
+    parserResult.dtdPtr = dtd;
+    errno = dtd == NULL ? -1 : 0;
+    return parserResult;
+}
+
+static struct dtdParserResult cParseUrlDtd(const char* url, const short int options) {
+    errCtx ectx = initErrCtx(1, GO_ERR_INIT);
+    if (options & P_ERR_VERBOSE) {
+        xmlSetGenericErrorFunc(&ectx, genErrorCallback);
+    } else {
+        xmlSetGenericErrorFunc(NULL, noOutputCallback);
+    }
+    xmlDtdPtr dtd = xmlParseDTD(NULL, BAD_CAST url);
+    return parseDtd(dtd, options, ectx);
+}
+
+static struct dtdParserResult cParseMemDtd(const void* dtd, const int goDtdSourceLen,
+                                           const short int options) {
+    errCtx ectx = initErrCtx(1, GO_ERR_INIT);
+    if (options & P_ERR_VERBOSE) {
+        xmlSetGenericErrorFunc(&ectx, genErrorCallback);
+    } else {
+        xmlSetGenericErrorFunc(NULL, noOutputCallback);
+    }
+    xmlDtdPtr dtdPtr = NULL;
+    xmlParserInputBufferPtr input =
+        xmlParserInputBufferCreateMem(dtd, goDtdSourceLen, XML_CHAR_ENCODING_NONE);
+    if (input != NULL) {
+        // xmlIOParseDTD takes ownership of the input buffer
+        dtdPtr = xmlIOParseDTD(NULL, input, XML_CHAR_ENCODING_NONE);
+    }
+    return parseDtd(dtdPtr, options, ectx);
+}
+
+// Validates doc against dtd or, if dtd is NULL, against the DOCTYPE of the document with ext as external subset.
+// The external subset the document declares is never loaded, without ext an empty one takes its place.
+static errArray cValidateDtd(const xmlDocPtr doc, const xmlDtdPtr dtd, const xmlDtdPtr ext) {
+    errArray errArr = initErrArray();
+
+    struct simpleXmlError simpleError = {0};
+    simpleError.message = calloc(GO_ERR_INIT, sizeof(char));
+    simpleError.node = calloc(GO_ERR_INIT, sizeof(char));
+
+    xmlValidCtxtPtr validCtxt = xmlNewValidCtxt();
+    if (validCtxt == NULL) {
+        simpleError.type = LIBXML2_ERROR;
+        strcpy(simpleError.message, "Xml validation internal error");
+        errArr.data[errArr.len] = simpleError;
+        errArr.len++;
+    } else {
+        // validity errors without a parser context are reported through the structured error handler
+        validCtxt->error = noOutputCallback;
+        validCtxt->warning = noOutputCallback;
+        xmlSetGenericErrorFunc(NULL, noOutputCallback);
+        xmlSetStructuredErrorFunc(&errArr, simpleStructErrorCallback);
+
+        xmlDtdPtr extSubset = doc->extSubset;
+        xmlDtdPtr empty = NULL;
+        if (dtd == NULL && extSubset == NULL && doc->intSubset != NULL) {
+            if (ext != NULL) {
+                doc->extSubset = ext;
+            } else if (doc->intSubset->SystemID != NULL || doc->intSubset->ExternalID != NULL) {
+                empty = xmlNewDtd(NULL, doc->intSubset->name, NULL, NULL);
+                doc->extSubset = empty;
+            }
+        }
+
+        int valid = dtd != NULL ? xmlValidateDtd(validCtxt, doc, dtd)
+                                : xmlValidateDocument(validCtxt, doc);
+
+        doc->extSubset = extSubset;
+        if (empty != NULL) {
+            xmlFreeDtd(empty);
+        }
+
+        xmlSetStructuredErrorFunc(NULL, NULL);
+        xmlFreeValidCtxt(validCtxt);
+
+        if (!valid && errArr.len == 0) {
+            simpleError.type = LIBXML2_ERROR;
+            strcpy(simpleError.message, "Xml validation internal error");
+            errArr.data[errArr.len] = simpleError;
+            errArr.len++;
+        } else {
+            free(simpleError.node);
+            free(simpleError.message);
+        }
+    }
+
+    errno = errArr.len == NO_ERROR ? 0 : -1;
+    return errArr;
+}
+
+static errArray cValidateBuf(const void* goXmlSource,
+                             const int goXmlSourceLen,
                              const short int xmlParserOptions,
                              const xmlSchemaPtr schema) {
     errArray errArr = initErrArray();
 
-    struct simpleXmlError simpleError;
+    struct simpleXmlError simpleError = {0};
     simpleError.message = calloc(GO_ERR_INIT, sizeof(char));
     simpleError.node = calloc(GO_ERR_INIT, sizeof(char));
 
     struct xmlParserResult parserResult =
-    cParseDoc(goXmlSource, goXmlSourceLen, xmlParserOptions);
+    cParseDoc(goXmlSource, goXmlSourceLen, NULL, xmlParserOptions);
 
     if (schema == NULL) {
         simpleError.type = LIBXML2_ERROR;
@@ -373,6 +2041,8 @@ static errArray cValidateBuf(const void* goXmlSource,
 
         xmlFreeDoc(parserResult.docPtr);
         free(parserResult.errorStr);
+        free(parserResult.encoding);
+        free(parserResult.columns);
         errno = -1;
         return errArr;
     } else if (parserResult.docPtr == NULL) {
@@ -380,25 +2050,233 @@ static errArray cValidateBuf(const void* goXmlSource,
         free(simpleError.message);
         simpleError.message = malloc(strlen(parserResult.errorStr) + 1);
         strcpy(simpleError.message, parserResult.errorStr);
+        simpleError.columns = parserResult.columns;
         errArr.data[errArr.len] = simpleError;
         errArr.len++;
 
-        xmlFreeDoc(parserResult.docPtr);
-        free(parserResult.errorStr);
-        errno = -1;
-        return errArr;
+        xmlFreeDoc(parserResult.docPtr);
+        free(parserResult.errorStr);
+        free(parserResult.encoding);
+        errno = -1;
+        return errArr;
+    }
+    free(simpleError.node);
+    free(simpleError.message);
+    freeErrArray(&errArr);
+    free(parserResult.errorStr);
+    free(parserResult.encoding);
+    free(parserResult.columns);
+
+    errArray valErrArr = cValidate(parserResult.docPtr, schema, NULL, xmlParserOptions);
+
+    xmlFreeDoc(parserResult.docPtr);
+
+    errno = valErrArr.len == NO_ERROR ? 0 : -1;
+    return valErrArr;
+}
+
+// Returns the {namespace}local name of an element or attribute, an empty name for other nodes
+static char* cNodeName(const xmlNodePtr node) {
+    if (node->type != XML_ELEMENT_NODE && node->type != XML_ATTRIBUTE_NODE) {
+        return calloc(1, sizeof(char));
+    }
+    const char* ns = node->ns != NULL ? (const char*)node->ns->href : "";
+    const char* local = node->name != NULL ? (const char*)node->name : "";
+    char* name = malloc(strlen(ns) + strlen(local) + 3);
+    if (*ns != '\0') {
+        sprintf(name, "{%s}%s", ns, local);
+    } else {
+        strcpy(name, local);
+    }
+    return name;
+}
+
+static char* cRootName(const xmlDocPtr doc) {
+    xmlNodePtr root = xmlDocGetRootElement(doc);
+    if (root == NULL) {
+        return NULL;
+    }
+    return cNodeName(root);
+}
+
+// Creates an xpath context with the prefixes declared on the root element registered
+static xmlXPathContextPtr cNewXPathContext(const xmlDocPtr doc) {
+    xmlSetGenericErrorFunc(NULL, noOutputCallback);
+    xmlXPathContextPtr xpathCtxt = xmlXPathNewContext(doc);
+    xmlNodePtr root = xmlDocGetRootElement(doc);
+    if (xpathCtxt != NULL && root != NULL) {
+        for (xmlNsPtr ns = root->nsDef; ns != NULL; ns = ns->next) {
+            if (ns->prefix != NULL) {
+                xmlXPathRegisterNs(xpathCtxt, ns->prefix, ns->href);
+            }
+        }
+    }
+    return xpathCtxt;
+}
+
+// Returns the line of an element, the line of the parent element for attributes, text, CDATA sections,
+// comments and processing instructions, 0 for other nodes
+static int cNodeLine(const xmlNodePtr node) {
+    xmlNodePtr elem = node;
+    switch (node->type) {
+    case XML_ATTRIBUTE_NODE:
+    case XML_TEXT_NODE:
+    case XML_CDATA_SECTION_NODE:
+    case XML_COMMENT_NODE:
+    case XML_PI_NODE:
+        elem = node->parent;
+        break;
+    default:
+        break;
+    }
+    return elem != NULL && elem->type == XML_ELEMENT_NODE ? (int)xmlGetLineNo(elem) : 0;
+}
+
+// Serializes doc with the xmlSaveOption flags, a NULL encoding keeps the encoding of the document or uses UTF-8
+static char* cDumpDoc(const xmlDocPtr doc, const char* encoding, const int options, int* size) {
+    xmlBufferPtr buf = xmlBufferCreate();
+    if (buf == NULL) {
+        return NULL;
+    }
+    if (encoding == NULL) {
+        encoding = doc->encoding != NULL ? (const char*)doc->encoding : "UTF-8";
+    }
+    xmlSaveCtxtPtr saveCtxt = xmlSaveToBuffer(buf, encoding, options);
+    if (saveCtxt == NULL) {
+        xmlBufferFree(buf);
+        return NULL;
+    }
+    xmlSaveDoc(saveCtxt, doc);
+    xmlSaveClose(saveCtxt);
+
+    *size = xmlBufferLength(buf);
+    char* mem = (char*)xmlBufferDetach(buf);
+    xmlBufferFree(buf);
+    return mem;
+}
+
+// Replaces the entity references in the list starting with node by the parsed content of their internal entities,
+// budget limits the characters of substituted entity content. External entities are not loaded.
+static bool substituteEntities(xmlDocPtr doc, xmlNodePtr node, size_t* budget, errCtx* ectx) {
+    xmlNodePtr next;
+    for (; node != NULL; node = next) {
+        next = node->next;
+        if (node->type == XML_ELEMENT_NODE) {
+            if (!substituteEntities(doc, node->children, budget, ectx)) {
+                return false;
+            }
+            continue;
+        }
+        if (node->type != XML_ENTITY_REF_NODE) {
+            continue;
+        }
+        xmlEntityPtr ent = xmlGetDocEntity(doc, node->name);
+        if (ent == NULL || ent->etype != XML_INTERNAL_GENERAL_ENTITY) {
+            appendErrCtxErrBuff(ectx, "Entity '");
+            appendErrCtxErrBuff(ectx, (const char*)node->name);
+            appendErrCtxErrBuff(ectx, "' is not an internal entity and cannot be substituted");
+            return false;
+        }
+        int len = xmlStrlen(ent->content);
+        if ((size_t)len > *budget) {
+            appendErrCtxErrBuff(ectx, "Entity substitution exceeds the size limit");
+            return false;
+        }
+        *budget -= len;
+        xmlNodePtr list = NULL;
+        if (len > 0 && xmlParseInNodeContext(node->parent, (const char*)ent->content, len, 0, &list) != XML_ERR_OK) {
+            xmlFreeNodeList(list);
+            appendErrCtxErrBuff(ectx, "Failed to parse the content of entity '");
+            appendErrCtxErrBuff(ectx, (const char*)node->name);
+            appendErrCtxErrBuff(ectx, "'");
+            return false;
+        }
+        xmlNodePtr prev = node->prev;
+        while (list != NULL) {
+            xmlNodePtr child = list;
+            list = list->next;
+            child->next = NULL;
+            // a text node may be merged into an adjacent one and freed
+            xmlAddPrevSibling(node, child);
+        }
+        // the substituted content is checked for nested references next
+        xmlNodePtr first = prev != NULL ? prev->next : node->parent->children;
+        if (first != node) {
+            next = first;
+        }
+        xmlUnlinkNode(node);
+        xmlFreeNode(node);
+    }
+    return true;
+}
+
+// Adds the default values of the attributes the dtd declares to the elements in the list starting with node that lack them
+static void addDtdDefaults(const xmlDtdPtr dtd, xmlNodePtr node) {
+    for (; node != NULL; node = node->next) {
+        if (node->type != XML_ELEMENT_NODE) {
+            continue;
+        }
+        xmlElementPtr decl = xmlGetDtdQElementDesc(dtd, node->name, node->ns != NULL ? node->ns->prefix : NULL);
+        for (xmlAttributePtr attr = decl != NULL ? decl->attributes : NULL; attr != NULL; attr = attr->nexth) {
+            if (attr->defaultValue == NULL || attr->def == XML_ATTRIBUTE_IMPLIED ||
+                xmlStrEqual(attr->prefix, BAD_CAST "xmlns") ||
+                (attr->prefix == NULL && xmlStrEqual(attr->name, BAD_CAST "xmlns"))) {
+                continue;
+            }
+            xmlNsPtr ns = NULL;
+            if (attr->prefix != NULL && (ns = xmlSearchNs(node->doc, node, attr->prefix)) == NULL) {
+                continue;
+            }
+            // xmlHasNsProp would find the declaration itself
+            bool present = false;
+            for (xmlAttrPtr prop = node->properties; prop != NULL && !present; prop = prop->next) {
+                present = xmlStrEqual(prop->name, attr->name) &&
+                          (ns != NULL ? prop->ns != NULL && xmlStrEqual(prop->ns->href, ns->href) : prop->ns == NULL);
+            }
+            if (!present) {
+                xmlSetNsProp(node, ns, attr->name, attr->defaultValue);
+            }
+        }
+        addDtdDefaults(dtd, node->children);
     }
-    free(simpleError.node);
-    free(simpleError.message);
-    freeErrArray(&errArr);
-    free(parserResult.errorStr);
+}
 
-    errArray valErrArr = cValidate(parserResult.docPtr, schema);
+// Copies a document with a DOCTYPE for canonicalization, which does not support entity references and
+// expects the default attributes to be present. The entities are substituted and the defaults of the internal subset added.
+static struct xmlParserResult cCanonicalCopy(const xmlDocPtr doc) {
+    struct xmlParserResult result = {0};
+    errCtx ectx = initErrCtx(1, GO_ERR_INIT);
+    xmlSetGenericErrorFunc(NULL, noOutputCallback);
+    xmlDocPtr copy = xmlCopyDoc(doc, 1);
+    size_t budget = XML_MAX_TEXT_LENGTH;
+    if (copy == NULL) {
+        appendErrCtxErrBuff(&ectx, "Failed to copy the document");
+    } else if (!substituteEntities(copy, copy->children, &budget, &ectx)) {
+        xmlFreeDoc(copy);
+        copy = NULL;
+    } else if (doc->intSubset != NULL) {
+        // xmlCopyDtd does not link the attribute declarations of the copy to their elements
+        addDtdDefaults(doc->intSubset, copy->children);
+    }
+    result.docPtr = copy;
+    result.errorStr = ectx.errBuf;
+    errno = copy == NULL ? -1 : 0;
+    return result;
+}
 
-    xmlFreeDoc(parserResult.docPtr);
+// Canonicalizes doc, the messages of libxml2 errors are appended to errorStr
+static int cCanonicalize(const xmlDocPtr doc, xmlNodeSetPtr nodes, const int mode,
+                         xmlChar** prefixes, const int withComments, xmlChar** mem, char** errorStr) {
+    errCtx ectx = initErrCtx(1, GO_ERR_INIT);
+    xmlSetGenericErrorFunc(&ectx, genErrorCallback);
+    int size = xmlC14NDocDumpMemory(doc, nodes, mode, prefixes, withComments, mem);
+    xmlSetGenericErrorFunc(NULL, noOutputCallback);
+    *errorStr = ectx.errBuf;
+    return size;
+}
 
-    errno = valErrArr.len == NO_ERROR ? 0 : -1;
-    return valErrArr;
+static void cXmlFree(void* ptr) {
+    xmlFree(ptr);
 }
 */
 import "C"
@@ -412,11 +2290,35 @@ import (
 // XsdHandler handles schema parsing and validation and wraps a pointer to libxml2's xmlSchemaPtr.
 type XsdHandler struct {
 	schemaPtr C.xmlSchemaPtr
+	roots     []QName
+	sensitive map[QName]bool
+}
+
+// RngHandler handles RelaxNG grammar parsing and validation and wraps a pointer to libxml2's xmlRelaxNGPtr.
+type RngHandler struct {
+	rngPtr C.xmlRelaxNGPtr
+}
+
+// DtdHandler handles DTD parsing and validation and wraps a pointer to libxml2's xmlDtdPtr.
+type DtdHandler struct {
+	dtdPtr C.xmlDtdPtr
+}
+
+// Node is an element, attribute or other node of a parsed document,
+// it is only valid as long as the XmlHandler it belongs to is not freed.
+type Node struct {
+	nodePtr C.xmlNodePtr
+}
+
+// SchematronHandler handles ISO Schematron rules and wraps a pointer to the libxml2 document of the schematron schema.
+type SchematronHandler struct {
+	sctPtr C.xmlDocPtr
 }
 
 // XmlHandler handles xml parsing and wraps a pointer to libxml2's xmlDocPtr.
 type XmlHandler struct {
-	docPtr C.xmlDocPtr
+	docPtr   C.xmlDocPtr
+	encoding string
 }
 
 // Initializes the libxml2 parser, suggested for multithreading
@@ -430,74 +2332,569 @@ func libXml2Cleanup() {
 }
 
 // The helper function for parsing xml
-func parseXmlMem(inXml []byte, options Options) (C.xmlDocPtr, error) {
+func parseXmlMem(inXml []byte, baseUrl string, options Options) (*XmlHandler, error) {
 	strXml := C.CBytes(inXml)
 	defer C.free(unsafe.Pointer(strXml))
-	pRes, err := C.cParseDoc(strXml, C.int(len(inXml)), C.short(options))
+	var strUrl *C.char
+	if baseUrl != "" {
+		strUrl = C.CString(baseUrl)
+		defer C.free(unsafe.Pointer(strUrl))
+	}
+	pRes, err := C.cParseDoc(strXml, C.int(len(inXml)), strUrl, C.short(options))
+	return xmlParserResult(pRes, err)
+}
+
+// The helper function for parsing an xml document from a file or URL
+func parseXmlUrl(url string, options Options) (*XmlHandler, error) {
+	strUrl := C.CString(url)
+	defer C.free(unsafe.Pointer(strUrl))
+	pRes, err := C.cParseUrlDoc(strUrl, C.short(options))
+	return xmlParserResult(pRes, err)
+}
 
+// Converts the result of parsing an xml document, the returned handler is never nil
+func xmlParserResult(pRes C.struct_xmlParserResult, err error) (*XmlHandler, error) {
 	defer C.free(unsafe.Pointer(pRes.errorStr))
+	defer C.free(unsafe.Pointer(pRes.encoding))
+	defer C.free(unsafe.Pointer(pRes.columns))
 	if err != nil {
 		rStr := C.GoString(pRes.errorStr)
-		return nil, XmlParserError{errorMessage{strings.Trim(rStr, "\n")}}
+		return &XmlHandler{}, XmlParserError{errorMessage: errorMessage{Message: strings.Trim(rStr, "\n")}, columns: C.GoString(pRes.columns)}
 	}
-	return pRes.docPtr, nil
+	return &XmlHandler{docPtr: pRes.docPtr, encoding: C.GoString(pRes.encoding)}, nil
 }
 
 // The helper function for parsing the schema
-func parseUrlSchema(url string, options Options) (C.xmlSchemaPtr, error) {
+func parseUrlSchema(url string, options Options) (*XsdHandler, error) {
 	strUrl := C.CString(url)
 	defer C.free(unsafe.Pointer(strUrl))
 
 	pRes, err := C.cParseUrlSchema(strUrl, C.short(options))
-	defer C.free(unsafe.Pointer(pRes.errorStr))
-	if err != nil {
-		rStr := C.GoString(pRes.errorStr)
-		return nil, XsdParserError{errorMessage{strings.Trim(rStr, "\n")}}
-	}
-	return pRes.schemaPtr, nil
+	return xsdParserResult(pRes, err)
 }
 
 // The helper function for parsing an in-memory schema
-func parseMemSchema(xsd []byte, options Options) (C.xmlSchemaPtr, error) {
+func parseMemSchema(xsd []byte, options Options) (*XsdHandler, error) {
 	strXsd := C.CBytes(xsd)
 	defer C.free(unsafe.Pointer(strXsd))
 
 	pRes, err := C.cParseMemSchema(strXsd, C.int(len(xsd)), C.short(options))
+	return xsdParserResult(pRes, err)
+}
+
+// Converts the result of parsing a schema and collects its sensitive declarations, the returned handler is never nil
+func xsdParserResult(pRes C.struct_xsdParserResult, err error) (*XsdHandler, error) {
+	defer C.free(unsafe.Pointer(pRes.errorStr))
+	if err != nil {
+		rStr := C.GoString(pRes.errorStr)
+		return &XsdHandler{}, XsdParserError{errorMessage{Message: strings.Trim(rStr, "\n")}}
+	}
+	return &XsdHandler{schemaPtr: pRes.schemaPtr, sensitive: schemaSensitiveNames(pRes.schemaPtr)}, nil
+}
+
+// The helper function for parsing a RelaxNG grammar
+func parseUrlRng(url string, options Options) (C.xmlRelaxNGPtr, error) {
+	strUrl := C.CString(url)
+	defer C.free(unsafe.Pointer(strUrl))
+
+	pRes, err := C.cParseUrlRng(strUrl, C.short(options))
+	defer C.free(unsafe.Pointer(pRes.errorStr))
+	if err != nil {
+		rStr := C.GoString(pRes.errorStr)
+		return nil, RngParserError{errorMessage{Message: strings.Trim(rStr, "\n")}}
+	}
+	return pRes.rngPtr, nil
+}
+
+// The helper function for parsing an in-memory RelaxNG grammar
+func parseMemRng(rng []byte, options Options) (C.xmlRelaxNGPtr, error) {
+	strRng := C.CBytes(rng)
+	defer C.free(unsafe.Pointer(strRng))
+
+	pRes, err := C.cParseMemRng(strRng, C.int(len(rng)), C.short(options))
+	defer C.free(unsafe.Pointer(pRes.errorStr))
+	if err != nil {
+		rStr := C.GoString(pRes.errorStr)
+		return nil, RngParserError{errorMessage{Message: strings.Trim(rStr, "\n")}}
+	}
+	return pRes.rngPtr, nil
+}
+
+// The helper function for parsing a schematron schema
+func parseUrlSct(url string, options Options) (C.xmlDocPtr, error) {
+	strUrl := C.CString(url)
+	defer C.free(unsafe.Pointer(strUrl))
+
+	pRes, err := C.cParseUrlSct(strUrl, C.short(options))
+	defer C.free(unsafe.Pointer(pRes.errorStr))
+	if err != nil {
+		rStr := C.GoString(pRes.errorStr)
+		return nil, SchematronParserError{errorMessage{Message: strings.Trim(rStr, "\n")}}
+	}
+	return pRes.docPtr, nil
+}
+
+// The helper function for parsing an in-memory schematron schema
+func parseMemSct(sct []byte, options Options) (C.xmlDocPtr, error) {
+	strSct := C.CBytes(sct)
+	defer C.free(unsafe.Pointer(strSct))
+
+	pRes, err := C.cParseMemSct(strSct, C.int(len(sct)), C.short(options))
+	defer C.free(unsafe.Pointer(pRes.errorStr))
+	if err != nil {
+		rStr := C.GoString(pRes.errorStr)
+		return nil, SchematronParserError{errorMessage{Message: strings.Trim(rStr, "\n")}}
+	}
+	return pRes.docPtr, nil
+}
+
+// The helper function for parsing a DTD
+func parseUrlDtd(url string, options Options) (C.xmlDtdPtr, error) {
+	strUrl := C.CString(url)
+	defer C.free(unsafe.Pointer(strUrl))
+
+	pRes, err := C.cParseUrlDtd(strUrl, C.short(options))
+	defer C.free(unsafe.Pointer(pRes.errorStr))
+	if err != nil {
+		rStr := C.GoString(pRes.errorStr)
+		return nil, DtdParserError{errorMessage{Message: strings.Trim(rStr, "\n")}}
+	}
+	return pRes.dtdPtr, nil
+}
+
+// The helper function for parsing an in-memory DTD
+func parseMemDtd(dtd []byte, options Options) (C.xmlDtdPtr, error) {
+	strDtd := C.CBytes(dtd)
+	defer C.free(unsafe.Pointer(strDtd))
+
+	pRes, err := C.cParseMemDtd(strDtd, C.int(len(dtd)), C.short(options))
 	defer C.free(unsafe.Pointer(pRes.errorStr))
 	if err != nil {
 		rStr := C.GoString(pRes.errorStr)
-		return nil, XsdParserError{errorMessage{strings.Trim(rStr, "\n")}}
+		return nil, DtdParserError{errorMessage{Message: strings.Trim(rStr, "\n")}}
 	}
-	return pRes.schemaPtr, nil
+	return pRes.dtdPtr, nil
 }
 
 func handleErrArray(errSlice []C.struct_simpleXmlError) ValidationError {
 	ve := ValidationError{make([]StructError, len(errSlice))}
 	for i := 0; i < len(errSlice); i++ {
+		message := strings.Trim(C.GoString(errSlice[i].message), "\n")
 		ve.Errors[i] = StructError{
-			Code:     int(errSlice[i].code),
-			Message:  strings.Trim(C.GoString(errSlice[i].message), "\n"),
-			Level:    int(errSlice[i].level),
-			Line:     int(errSlice[i].line),
-			NodeName: C.GoString(errSlice[i].node)}
+			Code:      int(errSlice[i].code),
+			Message:   message,
+			Level:     int(errSlice[i].level),
+			Line:      int(errSlice[i].line),
+			Column:    int(errSlice[i].column),
+			Domain:    int(errSlice[i].domain),
+			NodeName:  C.GoString(errSlice[i].node),
+			Path:      C.GoString(errSlice[i].path),
+			Value:     findSubmatch(reValue, message),
+			Expected:  parseExpected(message),
+			Found:     parseFound(message),
+			File:      C.GoString(errSlice[i].file),
+			Rule:      C.GoString(errSlice[i].rule),
+			Assertion: C.GoString(errSlice[i].assertion)}
 	}
 	return ve
 
 }
 
+// Converts the errors of a schema validation and adds suggestions and redactions
+func handleValidationErrArray(errSlice []C.struct_simpleXmlError, xsdHandler *XsdHandler, options Options) ValidationError {
+	ve := handleErrArray(errSlice)
+	addSuggestions(ve.Errors, xsdHandler)
+	redactValues(ve.Errors, xsdHandler, options)
+	return ve
+}
+
+// Returns the names of the global element declarations of the schema's target namespace
+func schemaGlobalElements(xsdHandler *XsdHandler) []QName {
+	cNames := C.cSchemaGlobalElements(xsdHandler.schemaPtr)
+	defer C.free(unsafe.Pointer(cNames))
+	var names []QName
+	for _, name := range strings.Split(C.GoString(cNames), "\n") {
+		if name != "" {
+			names = append(names, parseQName(name))
+		}
+	}
+	return names
+}
+
+// Returns the value sets of the enumeration facets of the schema
+func schemaEnumerations(xsdHandler *XsdHandler) [][]string {
+	cSets := C.cSchemaEnumerations(xsdHandler.schemaPtr)
+	defer C.free(unsafe.Pointer(cSets))
+	var sets [][]string
+	for _, set := range strings.Split(C.GoString(cSets), "\x1e") {
+		if set != "" {
+			sets = append(sets, strings.Split(strings.TrimSuffix(set, "\x1f"), "\x1f"))
+		}
+	}
+	return sets
+}
+
+// Returns the names of the element and attribute declarations marked as sensitive in the documents of a schema
+func schemaSensitiveNames(schemaPtr C.xmlSchemaPtr) map[QName]bool {
+	strNs := C.CString(AnnotationNamespace)
+	defer C.free(unsafe.Pointer(strNs))
+	cNames := C.cSensitiveNames(schemaPtr, strNs)
+	defer C.free(unsafe.Pointer(cNames))
+	sensitive := make(map[QName]bool)
+	for _, name := range strings.Split(C.GoString(cNames), "\n") {
+		if name != "" {
+			sensitive[parseQName(name)] = true
+		}
+	}
+	return sensitive
+}
+
+// Returns the name of the root element of an xml document
+func rootName(xmlHandler *XmlHandler) QName {
+	cName := C.cRootName(xmlHandler.docPtr)
+	if cName == nil {
+		return QName{}
+	}
+	defer C.free(unsafe.Pointer(cName))
+	return parseQName(C.GoString(cName))
+}
+
+// Returns the namespace qualified name of an element or attribute node, an empty QName for other nodes
+func nodeName(node Node) QName {
+	cName := C.cNodeName(node.nodePtr)
+	defer C.free(unsafe.Pointer(cName))
+	return parseQName(C.GoString(cName))
+}
+
+// Returns the line of a node, the line of the parent element for attributes, text, comments and processing instructions
+func nodeLine(node Node) int {
+	return int(C.cNodeLine(node.nodePtr))
+}
+
+// Returns the XPath-like location of a node
+func nodePath(node Node) string {
+	path := C.xmlGetNodePath(node.nodePtr)
+	defer C.cXmlFree(unsafe.Pointer(path))
+	return C.GoString((*C.char)(unsafe.Pointer(path)))
+}
+
+// Returns the text content of a node
+func nodeValue(node Node) string {
+	content := C.xmlNodeGetContent(node.nodePtr)
+	defer C.cXmlFree(unsafe.Pointer(content))
+	return C.GoString((*C.char)(unsafe.Pointer(content)))
+}
+
+// Returns the value of an attribute without namespace and whether the node has it
+func nodeAttr(node Node, name string) (string, bool) {
+	strName := C.CString(name)
+	defer C.free(unsafe.Pointer(strName))
+	value := C.xmlGetNoNsProp(node.nodePtr, (*C.xmlChar)(unsafe.Pointer(strName)))
+	if value == nil {
+		return "", false
+	}
+	defer C.cXmlFree(unsafe.Pointer(value))
+	return C.GoString((*C.char)(unsafe.Pointer(value))), true
+}
+
+// Returns the element children of a node
+func nodeChildren(node Node) []Node {
+	var children []Node
+	for child := node.nodePtr.children; child != nil; child = child.next {
+		if child._type == C.XML_ELEMENT_NODE {
+			children = append(children, Node{child})
+		}
+	}
+	return children
+}
+
+// Evaluates an xpath expression with context as context node, or the document if context is nil,
+// the prefixes declared on the root element are registered unless namespaces maps them, the returned object has to be freed with xmlXPathFreeObject
+func evalXPath(docPtr C.xmlDocPtr, context C.xmlNodePtr, expr string, namespaces map[string]string) (C.xmlXPathObjectPtr, error) {
+	xpathCtxt := C.cNewXPathContext(docPtr)
+	if xpathCtxt == nil {
+		return nil, Libxml2Error{errorMessage{Message: "Xpath internal error"}}
+	}
+	defer C.xmlXPathFreeContext(xpathCtxt)
+
+	for prefix, uri := range namespaces {
+		strPrefix := C.CString(prefix)
+		strUri := C.CString(uri)
+		C.xmlXPathRegisterNs(xpathCtxt, (*C.xmlChar)(unsafe.Pointer(strPrefix)), (*C.xmlChar)(unsafe.Pointer(strUri)))
+		C.free(unsafe.Pointer(strPrefix))
+		C.free(unsafe.Pointer(strUri))
+	}
+	if context != nil {
+		xpathCtxt.node = context
+	}
+
+	strExpr := C.CString(expr)
+	defer C.free(unsafe.Pointer(strExpr))
+	obj := C.xmlXPathEvalExpression((*C.xmlChar)(unsafe.Pointer(strExpr)), xpathCtxt)
+	if obj == nil {
+		return nil, XPathError{errorMessage{Message: "Invalid xpath expression " + expr}}
+	}
+	return obj, nil
+}
+
+// Returns the nodes of a node-set object, namespace nodes are skipped
+func nodeSet(obj C.xmlXPathObjectPtr) []Node {
+	if obj.nodesetval == nil || obj.nodesetval.nodeNr == 0 {
+		return nil
+	}
+	nodeTab := (*[1 << 30]C.xmlNodePtr)(unsafe.Pointer(obj.nodesetval.nodeTab))[:obj.nodesetval.nodeNr:obj.nodesetval.nodeNr]
+	nodes := make([]Node, 0, len(nodeTab))
+	for _, nodePtr := range nodeTab {
+		if nodePtr._type != C.XML_NAMESPACE_DECL {
+			nodes = append(nodes, Node{nodePtr})
+		}
+	}
+	return nodes
+}
+
+// Evaluates an xpath expression and converts the result
+func evalXPathResult(docPtr C.xmlDocPtr, context C.xmlNodePtr, expr string, namespaces map[string]string) (XPathResult, error) {
+	obj, err := evalXPath(docPtr, context, expr, namespaces)
+	if err != nil {
+		return XPathResult{}, err
+	}
+	defer C.xmlXPathFreeObject(obj)
+
+	var res XPathResult
+	switch obj._type {
+	case C.XPATH_NODESET:
+		res.Type = XPathNodeSet
+		res.nodes = nodeSet(obj)
+	case C.XPATH_BOOLEAN:
+		res.Type = XPathBoolean
+	case C.XPATH_NUMBER:
+		res.Type = XPathNumber
+	case C.XPATH_STRING:
+		res.Type = XPathString
+	default:
+		return XPathResult{}, XPathError{errorMessage{Message: "Unsupported result type of xpath expression " + expr}}
+	}
+
+	str := C.xmlXPathCastToString(obj)
+	defer C.cXmlFree(unsafe.Pointer(str))
+	res.str = C.GoString((*C.char)(unsafe.Pointer(str)))
+	res.num = float64(C.xmlXPathCastToNumber(obj))
+	res.boolean = C.xmlXPathCastToBoolean(obj) != 0
+	return res, nil
+}
+
+// Selects the nodes of an xpath expression evaluated on the document
+func selectNodes(xmlHandler *XmlHandler, expr string, namespaces map[string]string) ([]Node, error) {
+	obj, err := evalXPath(xmlHandler.docPtr, nil, expr, namespaces)
+	if err != nil {
+		return nil, err
+	}
+	defer C.xmlXPathFreeObject(obj)
+	if obj._type != C.XPATH_NODESET {
+		return nil, XPathError{errorMessage{Message: "Xpath expression " + expr + " does not select nodes"}}
+	}
+	return nodeSet(obj), nil
+}
+
+// Converts a libxml2 string, NULL becomes the empty string
+func xmlString(str *C.xmlChar) string {
+	return C.GoString((*C.char)(unsafe.Pointer(str)))
+}
+
+// Calls visit for node and its descendant elements in document order
+func walkElements(node C.xmlNodePtr, visit func(elem C.xmlNodePtr)) {
+	for ; node != nil; node = node.next {
+		if node._type == C.XML_ELEMENT_NODE {
+			visit(node)
+			walkElements(node.children, visit)
+		}
+	}
+}
+
+// Returns the namespace declarations of all elements in document order without duplicates
+func docNamespaces(xmlHandler *XmlHandler) []Namespace {
+	var namespaces []Namespace
+	seen := map[Namespace]bool{}
+	walkElements(C.xmlDocGetRootElement(xmlHandler.docPtr), func(elem C.xmlNodePtr) {
+		for ns := elem.nsDef; ns != nil; ns = ns.next {
+			namespace := Namespace{Prefix: xmlString(ns.prefix), URI: xmlString(ns.href)}
+			if !seen[namespace] {
+				seen[namespace] = true
+				namespaces = append(namespaces, namespace)
+			}
+		}
+	})
+	return namespaces
+}
+
+// Returns the values of the xsi:schemaLocation and xsi:noNamespaceSchemaLocation attributes of all elements in document order
+func docSchemaHints(xmlHandler *XmlHandler) (locations []string, noNamespaceLocations []string) {
+	walkElements(C.xmlDocGetRootElement(xmlHandler.docPtr), func(elem C.xmlNodePtr) {
+		for attr := elem.properties; attr != nil; attr = attr.next {
+			if attr.ns == nil || xmlString(attr.ns.href) != C.XSI_NS {
+				continue
+			}
+			value := C.xmlNodeListGetString(xmlHandler.docPtr, attr.children, 1)
+			switch xmlString(attr.name) {
+			case "schemaLocation":
+				locations = append(locations, xmlString(value))
+			case "noNamespaceSchemaLocation":
+				noNamespaceLocations = append(noNamespaceLocations, xmlString(value))
+			}
+			C.cXmlFree(unsafe.Pointer(value))
+		}
+	})
+	return locations, noNamespaceLocations
+}
+
+// Returns the DOCTYPE of a document and whether it has one
+func docDoctype(xmlHandler *XmlHandler) (Doctype, bool) {
+	dtd := xmlHandler.docPtr.intSubset
+	if dtd == nil {
+		return Doctype{}, false
+	}
+	return Doctype{
+		Name:           xmlString(dtd.name),
+		PublicID:       xmlString(dtd.ExternalID),
+		SystemID:       xmlString(dtd.SystemID),
+		InternalSubset: dtd.children != nil,
+	}, true
+}
+
+// Returns the version, the declared encoding and the standalone flag of the xml declaration of a document,
+// standalone is -1 without xml declaration, -2 if the declaration has no standalone attribute, otherwise 0 or 1
+func docDeclaration(xmlHandler *XmlHandler) (version string, encoding string, standalone int) {
+	return xmlString(xmlHandler.docPtr.version), xmlString(xmlHandler.docPtr.encoding), int(xmlHandler.docPtr.standalone)
+}
+
+// Checks the root element of an xml document against the allowed root elements of the xsdHandler
+func checkRoot(xmlHandler *XmlHandler, xsdHandler *XsdHandler) error {
+	if len(xsdHandler.roots) == 0 {
+		return nil
+	}
+	root := rootName(xmlHandler)
+	for _, allowed := range xsdHandler.roots {
+		if root == allowed {
+			return nil
+		}
+	}
+	return RootElementError{
+		errorMessage{"Root element '" + root.String() + "' is not allowed", ErrRootNotAllowed},
+		root,
+		xsdHandler.roots,
+	}
+}
+
 // Helper function for validating given an xml document
-func validateWithXsd(xmlHandler *XmlHandler, xsdHandler *XsdHandler) error {
-	sErr, err := C.cValidate(xmlHandler.docPtr, xsdHandler.schemaPtr)
+func validateWithXsd(xmlHandler *XmlHandler, xsdHandler *XsdHandler, options Options) error {
+	if err := checkRoot(xmlHandler, xsdHandler); err != nil {
+		return err
+	}
+	return validateElemWithXsd(xmlHandler, nil, xsdHandler, options)
+}
+
+// Helper function for validating the first element selected by an xpath expression
+func validateXPathWithXsd(xmlHandler *XmlHandler, xpath string, xsdHandler *XsdHandler, options Options) error {
+	obj, err := evalXPath(xmlHandler.docPtr, nil, xpath, nil)
+	if err != nil {
+		return err
+	}
+	defer C.xmlXPathFreeObject(obj)
+	var elem C.xmlNodePtr
+	if obj._type == C.XPATH_NODESET {
+		for _, node := range nodeSet(obj) {
+			if node.nodePtr._type == C.XML_ELEMENT_NODE {
+				elem = node.nodePtr
+				break
+			}
+		}
+	}
+	if elem == nil {
+		return XPathError{errorMessage{Message: "Xpath expression " + xpath + " selects no element"}}
+	}
+	return validateElemWithXsd(xmlHandler, elem, xsdHandler, options)
+}
+
+// Helper function for validating an xml document or one of its elements
+func validateElemWithXsd(xmlHandler *XmlHandler, elem C.xmlNodePtr, xsdHandler *XsdHandler, options Options) error {
+	sErr, err := C.cValidate(xmlHandler.docPtr, xsdHandler.schemaPtr, elem, C.short(options))
+	defer C.freeErrArray(&sErr)
+	if err != nil {
+		errSlice := (*[1 << 30]C.struct_simpleXmlError)(unsafe.Pointer(sErr.data))[:sErr.len:sErr.len]
+		return handleValidationErrArray(errSlice, xsdHandler, options)
+	}
+	return nil
+}
+
+// Helper function for validating given an xml document against a RelaxNG grammar
+func validateWithRng(xmlHandler *XmlHandler, rngHandler *RngHandler, options Options) error {
+	sErr, err := C.cValidateRng(xmlHandler.docPtr, rngHandler.rngPtr)
+	defer C.freeErrArray(&sErr)
+	if err != nil {
+		errSlice := (*[1 << 30]C.struct_simpleXmlError)(unsafe.Pointer(sErr.data))[:sErr.len:sErr.len]
+		if errSlice[0]._type == C.LIBXML2_ERROR {
+			return Libxml2Error{errorMessage{Message: C.GoString(errSlice[0].message)}}
+		}
+		ve := handleErrArray(errSlice)
+		redactValues(ve.Errors, nil, options)
+		return ve
+	}
+	return nil
+}
+
+// Helper function for validating given an xml document against a DTD, a nil dtdPtr validates against the DOCTYPE of the document
+func validateWithDtd(xmlHandler *XmlHandler, dtdPtr C.xmlDtdPtr, extPtr C.xmlDtdPtr, options Options) error {
+	sErr, err := C.cValidateDtd(xmlHandler.docPtr, dtdPtr, extPtr)
+	defer C.freeErrArray(&sErr)
+	if err != nil {
+		errSlice := (*[1 << 30]C.struct_simpleXmlError)(unsafe.Pointer(sErr.data))[:sErr.len:sErr.len]
+		if errSlice[0]._type == C.LIBXML2_ERROR {
+			return Libxml2Error{errorMessage{Message: C.GoString(errSlice[0].message)}}
+		}
+		ve := handleErrArray(errSlice)
+		redactValues(ve.Errors, nil, options)
+		return ve
+	}
+	return nil
+}
+
+// Helper function for running the rules of a schematron schema against an xml document
+func validateWithSct(xmlHandler *XmlHandler, sctHandler *SchematronHandler, options Options) error {
+	sErr, err := C.cValidateSct(xmlHandler.docPtr, sctHandler.sctPtr, C.bool(options&ValidErrRedact != 0))
 	defer C.freeErrArray(&sErr)
 	if err != nil {
 		errSlice := (*[1 << 30]C.struct_simpleXmlError)(unsafe.Pointer(sErr.data))[:sErr.len:sErr.len]
-		return handleErrArray(errSlice)
+		if errSlice[0]._type == C.LIBXML2_ERROR {
+			return Libxml2Error{errorMessage{Message: C.GoString(errSlice[0].message)}}
+		}
+		ve := handleErrArray(errSlice)
+		redactValues(ve.Errors, nil, options)
+		return ve
 	}
 	return nil
 }
 
+// Helper function for parsing an xml byte slice and validating the document with validate
+func validateBufWith(inXml []byte, options Options, validate func(*XmlHandler) error) error {
+	xmlHandler, err := parseXmlMem(inXml, "", options)
+	if err != nil {
+		return err
+	}
+	defer xmlHandler.Free()
+	return validate(xmlHandler)
+}
+
 // Helper function for validating given an xml byte slice
 func validateBufWithXsd(inXml []byte, options Options, xsdHandler *XsdHandler) error {
+	if len(xsdHandler.roots) > 0 {
+		// The root element has to be checked before validation, so the document is kept
+		xmlHandler, err := parseXmlMem(inXml, "", options)
+		if err != nil {
+			return err
+		}
+		defer xmlHandler.Free()
+		return validateWithXsd(xmlHandler, xsdHandler, options)
+	}
 	strXml := C.CBytes(inXml)
 	defer C.free(unsafe.Pointer(strXml))
 	sErr, err := C.cValidateBuf(strXml, C.int(len(inXml)), C.short(options), xsdHandler.schemaPtr)
@@ -506,21 +2903,128 @@ func validateBufWithXsd(inXml []byte, options Options, xsdHandler *XsdHandler) e
 		errSlice := (*[1 << 30]C.struct_simpleXmlError)(unsafe.Pointer(sErr.data))[:sErr.len:sErr.len]
 		switch errSlice[0]._type {
 		case C.VALIDATION_ERROR:
-			return handleErrArray(errSlice)
+			return handleValidationErrArray(errSlice, xsdHandler, options)
 		case C.XML_PARSER_ERROR:
-			return XmlParserError{errorMessage{strings.Trim(C.GoString(errSlice[0].message), "\n")}}
+			return XmlParserError{errorMessage: errorMessage{Message: strings.Trim(C.GoString(errSlice[0].message), "\n")}, columns: C.GoString(errSlice[0].columns)}
 		case C.LIBXML2_ERROR:
-			return Libxml2Error{errorMessage{strings.Trim(C.GoString(errSlice[0].message), "\n")}}
+			return Libxml2Error{errorMessage{Message: strings.Trim(C.GoString(errSlice[0].message), "\n")}}
 		case C.XSD_PARSER_ERROR:
-			return XsdParserError{errorMessage{strings.Trim(C.GoString(errSlice[0].message), "\n")}}
+			return XsdParserError{errorMessage{Message: strings.Trim(C.GoString(errSlice[0].message), "\n")}}
 		default:
-			return Libxml2Error{errorMessage{"Unknown error"}}
+			return Libxml2Error{errorMessage{Message: "Unknown error"}}
 		}
-		return ValidationError{}
 	}
 	return nil
 }
 
+// Helper function for serializing an xml document
+func dumpDoc(xmlHandler *XmlHandler, options SaveOptions) ([]byte, error) {
+	var size C.int
+	var saveOptions C.int
+	if options.Format {
+		saveOptions |= C.XML_SAVE_FORMAT
+	}
+	if options.NoDeclaration {
+		saveOptions |= C.XML_SAVE_NO_DECL
+	}
+	if options.ExpandEmpty {
+		saveOptions |= C.XML_SAVE_NO_EMPTY
+	}
+
+	var strEncoding *C.char
+	if options.Encoding != "" {
+		strEncoding = C.CString(options.Encoding)
+		defer C.free(unsafe.Pointer(strEncoding))
+	}
+
+	mem := C.cDumpDoc(xmlHandler.docPtr, strEncoding, saveOptions, &size)
+	if mem == nil {
+		if options.Encoding != "" {
+			return nil, Libxml2Error{errorMessage{Message: "Xml serialization failed, unsupported encoding " + options.Encoding}}
+		}
+		return nil, Libxml2Error{errorMessage{Message: "Xml serialization failed"}}
+	}
+	defer C.cXmlFree(unsafe.Pointer(mem))
+	return C.GoBytes(unsafe.Pointer(mem), size), nil
+}
+
+// Canonicalizes the document of the xmlHandler or the node set selected by options.XPath,
+// documents with a DOCTYPE are canonicalized from a copy with their entities substituted and default attributes added
+func canonicalize(xmlHandler *XmlHandler, mode C14NMode, options C14NOptions) ([]byte, error) {
+	var cMode C.int
+	switch mode {
+	case C14N10:
+		cMode = C.XML_C14N_1_0
+	case C14N11:
+		cMode = C.XML_C14N_1_1
+	case ExclusiveC14N10:
+		cMode = C.XML_C14N_EXCLUSIVE_1_0
+	default:
+		return nil, Libxml2Error{errorMessage{Message: "Unknown canonicalization mode"}}
+	}
+
+	docPtr := xmlHandler.docPtr
+	if docPtr.intSubset != nil {
+		pRes, err := C.cCanonicalCopy(docPtr)
+		defer C.free(unsafe.Pointer(pRes.errorStr))
+		if err != nil {
+			return nil, Libxml2Error{errorMessage{Message: "Xml canonicalization failed: " + C.GoString(pRes.errorStr)}}
+		}
+		defer C.xmlFreeDoc(pRes.docPtr)
+		docPtr = pRes.docPtr
+	}
+
+	var nodes C.xmlNodeSetPtr
+	if options.XPath != "" {
+		obj, err := evalXPath(docPtr, nil, options.XPath, options.Namespaces)
+		if err != nil {
+			return nil, err
+		}
+		defer C.xmlXPathFreeObject(obj)
+		if obj._type != C.XPATH_NODESET {
+			return nil, XPathError{errorMessage{Message: "Xpath expression " + options.XPath + " does not select nodes"}}
+		}
+		nodes = obj.nodesetval
+		if nodes == nil {
+			// an empty node set canonicalizes to nothing, whereas a nil node set means the whole document
+			return []byte{}, nil
+		}
+	}
+
+	var prefixes **C.xmlChar
+	if mode == ExclusiveC14N10 && len(options.InclusivePrefixes) > 0 {
+		n := len(options.InclusivePrefixes)
+		arr := C.calloc(C.size_t(n+1), C.size_t(unsafe.Sizeof(prefixes)))
+		defer C.free(arr)
+		prefixSlice := (*[1 << 30]*C.xmlChar)(arr)[: n+1 : n+1]
+		for i, prefix := range options.InclusivePrefixes {
+			strPrefix := C.CString(prefix)
+			defer C.free(unsafe.Pointer(strPrefix))
+			prefixSlice[i] = (*C.xmlChar)(unsafe.Pointer(strPrefix))
+		}
+		prefixes = (**C.xmlChar)(arr)
+	}
+
+	withComments := C.int(0)
+	if options.WithComments {
+		withComments = 1
+	}
+
+	var mem *C.xmlChar
+	var errorStr *C.char
+	size := C.cCanonicalize(docPtr, nodes, cMode, prefixes, withComments, &mem, &errorStr)
+	defer C.free(unsafe.Pointer(errorStr))
+	if size < 0 {
+		msg := "Xml canonicalization failed"
+		if detail := strings.TrimSpace(C.GoString(errorStr)); detail != "" {
+			msg += ": " + detail
+		}
+		return nil, Libxml2Error{errorMessage{Message: msg}}
+	}
+	defer C.cXmlFree(unsafe.Pointer(mem))
+	return C.GoBytes(unsafe.Pointer(mem), size), nil
+}
+
 // Wrapper for the xmlSchemaFree function
 func freeSchemaPtr(xsdHandler *XsdHandler) {
 	if xsdHandler.schemaPtr != nil {
@@ -528,6 +3032,27 @@ func freeSchemaPtr(xsdHandler *XsdHandler) {
 	}
 }
 
+// Wrapper for the xmlRelaxNGFree function
+func freeRngPtr(rngHandler *RngHandler) {
+	if rngHandler.rngPtr != nil {
+		C.xmlRelaxNGFree(rngHandler.rngPtr)
+	}
+}
+
+// Wrapper for the xmlFreeDtd function
+func freeDtdPtr(dtdHandler *DtdHandler) {
+	if dtdHandler.dtdPtr != nil {
+		C.xmlFreeDtd(dtdHandler.dtdPtr)
+	}
+}
+
+// Frees the document of a schematron schema
+func freeSctPtr(sctHandler *SchematronHandler) {
+	if sctHandler.sctPtr != nil {
+		C.xmlFreeDoc(sctHandler.sctPtr)
+	}
+}
+
 // Wrapper for the xmlFreeDoc function
 func freeDocPtr(xmlHandler *XmlHandler) {
 	if xmlHandler.docPtr != nil {
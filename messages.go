@@ -0,0 +1,188 @@
+package xsdvalidate
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// QName is a namespace qualified element or attribute name as reported by libxml2.
+type QName struct {
+	Namespace string
+	Local     string
+}
+
+// Implementation of the Stringer interface, uses the {namespace}local notation of libxml2.
+func (q QName) String() string {
+	if q.Namespace == "" {
+		return q.Local
+	}
+	return "{" + q.Namespace + "}" + q.Local
+}
+
+// The rightmost caret column of a parser error whose context line is known to start at the beginning of the line.
+const maxCaretColumn = 79
+
+var (
+	reExpected       = regexp.MustCompile(`Expected is (?:one of )?\( (.*) \)\.`)
+	reNotExpected    = regexp.MustCompile(`^Element '([^']+)': This element is not expected\.`)
+	reParserLine     = regexp.MustCompile(`^(?:Entity: line |(.*?):)(\d+): (?:element [^:]*: )?[\w ]*?(warning|error) : (.*)$`)
+	reCaret          = regexp.MustCompile(`^\s*\^$`)
+	reQuoted         = regexp.MustCompile(`'[^']*'`)
+	reElement        = regexp.MustCompile(`^Element '([^']+)'`)
+	reAttribute      = regexp.MustCompile(`^Element '[^']+', attribute '([^']+)'|The attribute '([^']+)'`)
+	reAttributeValue = regexp.MustCompile(`^Element '[^']+', attribute '[^']+': `)
+	reType           = regexp.MustCompile(`type '([^']+)'\.$`)
+	reLimit          = regexp.MustCompile(`\('([^']*)'\)\.$|of '([^']*)'\.$|pattern '(.*)'\.$|the set \{(.*)\}\.$`)
+	rePosition       = regexp.MustCompile(`\[\d+\]`)
+)
+
+// Finds the document values in xsd, dtd and RelaxNG messages. libxml2 does not escape quotes in values,
+// the greedy groups end at the text following the value.
+var reValue = regexp.MustCompile(`The value '(.*)' (?:is|has|must) |: '(.*)' is not a valid value|the value '(.*)' of |` +
+	`key-sequence \['(.*)'\] (?:in|of) |^Value "(.*)" for attribute |^ID (.*) already defined$|unknown ID "(.*)"$|allow value '(.*)'$`)
+
+// Parses a name in libxml2's {namespace}local notation.
+func parseQName(s string) QName {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "{") {
+		if i := strings.Index(s, "}"); i > 0 {
+			return QName{Namespace: s[1:i], Local: s[i+1:]}
+		}
+	}
+	return QName{Local: s}
+}
+
+// Extracts the expected element names from libxml2 content model messages.
+func parseExpected(message string) []QName {
+	m := reExpected.FindStringSubmatch(message)
+	if m == nil {
+		return nil
+	}
+	var expected []QName
+	for _, name := range strings.Split(m[1], ", ") {
+		if name == "..." || name == "" {
+			continue
+		}
+		expected = append(expected, parseQName(name))
+	}
+	return expected
+}
+
+// Extracts the name of an element that is not allowed at its position.
+func parseFound(message string) QName {
+	m := reNotExpected.FindStringSubmatch(message)
+	if m == nil {
+		return QName{}
+	}
+	return parseQName(m[1])
+}
+
+// Splits the text of an XmlParserError or XsdParserError into StructErrors.
+// Line numbers are only available with ParsErrVerbose, context and caret lines printed by libxml2 are dropped,
+// the column is taken from the caret. libxml2 prints at most maxCaretColumn bytes of context before the error position,
+// a caret further right may belong to a truncated line and is dropped instead of reporting a wrong column.
+// XmlParserError carries the columns libxml2 reports, see setColumns.
+func parserStructErrors(message string, domain int) []StructError {
+	level := LevelError
+	if domain == DomainParser {
+		level = LevelFatal
+	}
+	lines := strings.Split(message, "\n")
+	var errs []StructError
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" || reCaret.MatchString(line) ||
+			(i+1 < len(lines) && reCaret.MatchString(lines[i+1])) {
+			continue
+		}
+		se := StructError{Message: line, Level: level, Domain: domain}
+		if m := reParserLine.FindStringSubmatch(line); m != nil {
+			se.File = m[1]
+			se.Line, _ = strconv.Atoi(m[2])
+			se.Message = m[4]
+			if m[3] == "warning" {
+				se.Level = LevelWarning
+			}
+			if i+2 < len(lines) && reCaret.MatchString(lines[i+2]) {
+				if column := strings.Index(lines[i+2], "^") + 1; column <= maxCaretColumn {
+					se.Column = column
+				}
+			}
+		}
+		errs = append(errs, se)
+	}
+	return errs
+}
+
+// Sets the columns of the errors with a line from the space separated columns libxml2 reported for them, 0 is unknown.
+func setColumns(errs []StructError, columns string) {
+	fields := strings.Fields(columns)
+	k := 0
+	for i := range errs {
+		if errs[i].Line == 0 {
+			continue
+		}
+		errs[i].Column = 0
+		if k < len(fields) {
+			errs[i].Column, _ = strconv.Atoi(fields[k])
+		}
+		k++
+	}
+}
+
+// Replaces the quoted names and values in a libxml2 message with placeholders.
+func messageTemplate(message string) string {
+	return reQuoted.ReplaceAllString(message, "'%s'")
+}
+
+// Removes the positional predicates of a node path, e.g. /order/item[3]/title becomes /order/item/title.
+func schemaPath(path string) string {
+	return rePosition.ReplaceAllString(path, "")
+}
+
+// Returns the first non-empty submatch of a regexp with alternatives.
+func firstSubmatch(re *regexp.Regexp, s string) string {
+	for _, m := range re.FindStringSubmatch(s)[1:] {
+		if m != "" {
+			return m
+		}
+	}
+	return ""
+}
+
+// Returns the first submatch of a regexp with alternatives or an empty string if there is no match.
+func findSubmatch(re *regexp.Regexp, s string) string {
+	if !re.MatchString(s) {
+		return ""
+	}
+	return firstSubmatch(re, s)
+}
+
+// Extracts the parameters of a StructError used by message templates.
+func messageParams(e StructError) map[string]string {
+	expected := make([]string, len(e.Expected))
+	for i, q := range e.Expected {
+		expected[i] = q.String()
+	}
+	found := ""
+	if e.Found != (QName{}) {
+		found = e.Found.String()
+	}
+	return map[string]string{
+		"message":     e.Message,
+		"code":        strconv.Itoa(e.Code),
+		"line":        strconv.Itoa(e.Line),
+		"column":      strconv.Itoa(e.Column),
+		"node":        e.NodeName,
+		"path":        e.Path,
+		"found":       found,
+		"expected":    strings.Join(expected, ", "),
+		"suggestions": strings.Join(e.Suggestions, ", "),
+		"attribute":   findSubmatch(reAttribute, e.Message),
+		"value":       findSubmatch(reValue, e.Message),
+		"type":        findSubmatch(reType, e.Message),
+		"limit":       findSubmatch(reLimit, e.Message),
+		"rule":        e.Rule,
+		"assertion":   e.Assertion,
+	}
+}
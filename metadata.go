@@ -0,0 +1,109 @@
+package xsdvalidate
+
+import "strings"
+
+// Namespace is a namespace declaration, Prefix is empty for the default namespace.
+type Namespace struct {
+	Prefix string
+	URI    string
+}
+
+// Doctype is the document type declaration of a document, InternalSubset is true if it declares markup in brackets.
+type Doctype struct {
+	Name           string
+	PublicID       string
+	SystemID       string
+	InternalSubset bool
+}
+
+// SchemaLocation is a namespace and location pair of an xsi:schemaLocation hint.
+type SchemaLocation struct {
+	Namespace string
+	Location  string
+}
+
+// Root returns the namespace qualified name of the root element.
+// Like the other metadata accessors it returns the zero value for an xmlHandler whose document failed to parse.
+func (xmlHandler *XmlHandler) Root() QName {
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return QName{}
+	}
+	return rootName(xmlHandler)
+}
+
+// Namespaces returns the namespace declarations of all elements in document order, each prefix and uri pair once.
+func (xmlHandler *XmlHandler) Namespaces() []Namespace {
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return nil
+	}
+	return docNamespaces(xmlHandler)
+}
+
+// Version returns the xml version of the document, "1.0" if it has no xml declaration.
+func (xmlHandler *XmlHandler) Version() string {
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return ""
+	}
+	version, _, _ := docDeclaration(xmlHandler)
+	return version
+}
+
+// DeclaredEncoding returns the encoding of the xml declaration, empty if the document does not declare one.
+func (xmlHandler *XmlHandler) DeclaredEncoding() string {
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return ""
+	}
+	_, encoding, _ := docDeclaration(xmlHandler)
+	return encoding
+}
+
+// DetectedEncoding returns the encoding libxml2 decoded the document with, detected from a byte order mark
+// or the first bytes and switched to the declared encoding, e.g. "UTF-8" or "UTF-16LE".
+func (xmlHandler *XmlHandler) DetectedEncoding() string {
+	if xmlHandler == nil {
+		return ""
+	}
+	return xmlHandler.encoding
+}
+
+// Standalone returns the standalone flag of the xml declaration and whether the document declares it.
+func (xmlHandler *XmlHandler) Standalone() (standalone bool, declared bool) {
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return false, false
+	}
+	_, _, flag := docDeclaration(xmlHandler)
+	return flag == 1, flag >= 0
+}
+
+// Doctype returns the DOCTYPE of the document and whether it has one.
+func (xmlHandler *XmlHandler) Doctype() (Doctype, bool) {
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return Doctype{}, false
+	}
+	return docDoctype(xmlHandler)
+}
+
+// SchemaLocations returns the pairs of the xsi:schemaLocation attributes of all elements in document order.
+func (xmlHandler *XmlHandler) SchemaLocations() []SchemaLocation {
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return nil
+	}
+	var locations []SchemaLocation
+	hints, _ := docSchemaHints(xmlHandler)
+	for _, hint := range hints {
+		fields := strings.Fields(hint)
+		for i := 0; i+1 < len(fields); i += 2 {
+			locations = append(locations, SchemaLocation{Namespace: fields[i], Location: fields[i+1]})
+		}
+	}
+	return locations
+}
+
+// NoNamespaceSchemaLocations returns the values of the xsi:noNamespaceSchemaLocation attributes of all elements in document order.
+func (xmlHandler *XmlHandler) NoNamespaceSchemaLocations() []string {
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return nil
+	}
+	_, hints := docSchemaHints(xmlHandler)
+	return hints
+}
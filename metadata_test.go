@@ -0,0 +1,82 @@
+//go:build apitest
+// +build apitest
+
+package xsdvalidate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestXmlHandlerMetadata(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	inXml := []byte(`<?xml version="1.0" encoding="ISO-8859-1" standalone="no"?>
+<!DOCTYPE o:orders SYSTEM "orders.dtd" [
+	<!ENTITY company "ACME">
+]>
+<o:orders xmlns:o="urn:orders" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+	xsi:schemaLocation="urn:orders orders.xsd  urn:items items.xsd">
+	<item xmlns="urn:items" xsi:noNamespaceSchemaLocation="item.xsd"/>
+	<o:order xmlns:o="urn:orders"/>
+</o:orders>`)
+	xmlHandler, err := NewXmlHandlerMem(inXml, ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xmlHandler.Free()
+
+	if root := xmlHandler.Root(); root != (QName{Namespace: "urn:orders", Local: "orders"}) {
+		t.Errorf("unexpected root %v", root)
+	}
+	namespaces := []Namespace{{"o", "urn:orders"}, {"xsi", "http://www.w3.org/2001/XMLSchema-instance"}, {"", "urn:items"}}
+	if got := xmlHandler.Namespaces(); !reflect.DeepEqual(got, namespaces) {
+		t.Errorf("unexpected namespaces %v", got)
+	}
+	if xmlHandler.Version() != "1.0" || xmlHandler.DeclaredEncoding() != "ISO-8859-1" || xmlHandler.DetectedEncoding() != "ISO-8859-1" {
+		t.Errorf("unexpected declaration %q %q %q", xmlHandler.Version(), xmlHandler.DeclaredEncoding(), xmlHandler.DetectedEncoding())
+	}
+	if standalone, declared := xmlHandler.Standalone(); standalone || !declared {
+		t.Errorf("unexpected standalone %v %v", standalone, declared)
+	}
+	if doctype, ok := xmlHandler.Doctype(); !ok || doctype != (Doctype{Name: "o:orders", SystemID: "orders.dtd", InternalSubset: true}) {
+		t.Errorf("unexpected doctype %v", doctype)
+	}
+	locations := []SchemaLocation{{"urn:orders", "orders.xsd"}, {"urn:items", "items.xsd"}}
+	if got := xmlHandler.SchemaLocations(); !reflect.DeepEqual(got, locations) {
+		t.Errorf("unexpected schema locations %v", got)
+	}
+	if got := xmlHandler.NoNamespaceSchemaLocations(); !reflect.DeepEqual(got, []string{"item.xsd"}) {
+		t.Errorf("unexpected no namespace schema locations %v", got)
+	}
+}
+
+func TestXmlHandlerMetadataDefaults(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xmlHandler, err := NewXmlHandlerMem([]byte("\xff\xfe<\x00a\x00/\x00>\x00"), ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xmlHandler.Free()
+
+	if xmlHandler.Version() != "1.0" || xmlHandler.DeclaredEncoding() != "" || xmlHandler.DetectedEncoding() != "UTF-16LE" {
+		t.Errorf("unexpected declaration %q %q %q", xmlHandler.Version(), xmlHandler.DeclaredEncoding(), xmlHandler.DetectedEncoding())
+	}
+	if _, declared := xmlHandler.Standalone(); declared {
+		t.Error("expected no standalone declaration")
+	}
+	if _, ok := xmlHandler.Doctype(); ok {
+		t.Error("expected no doctype")
+	}
+	if xmlHandler.Root() != (QName{Local: "a"}) || xmlHandler.Namespaces() != nil || xmlHandler.SchemaLocations() != nil {
+		t.Error("unexpected root or namespaces")
+	}
+
+	failed, _ := NewXmlHandlerMem([]byte("<a>"), ParsErrDefault)
+	if failed.Root() != (QName{}) || failed.DetectedEncoding() != "" {
+		t.Error("expected zero values for a document that failed to parse")
+	}
+}
@@ -0,0 +1,32 @@
+package xsdvalidate
+
+// Name returns the namespace qualified name of the node, an empty QName for nodes other than elements and attributes.
+func (node Node) Name() QName {
+	return nodeName(node)
+}
+
+// Line returns the line of the node in the parsed document, for attributes, text, comments and processing instructions
+// the line of their parent element, 0 for the document node.
+func (node Node) Line() int {
+	return nodeLine(node)
+}
+
+// Path returns the XPath-like location of the node, e.g. /shiporder/item[2]/price.
+func (node Node) Path() string {
+	return nodePath(node)
+}
+
+// Value returns the text content of the node, for elements the concatenated text of all descendants.
+func (node Node) Value() string {
+	return nodeValue(node)
+}
+
+// Attr returns the value of the attribute without namespace and whether the node has it.
+func (node Node) Attr(name string) (string, bool) {
+	return nodeAttr(node, name)
+}
+
+// Children returns the child elements of the node.
+func (node Node) Children() []Node {
+	return nodeChildren(node)
+}
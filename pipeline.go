@@ -0,0 +1,107 @@
+package xsdvalidate
+
+// Validator validates a parsed document, it is implemented by XsdHandler, RngHandler, SchematronHandler, DtdHandler, Rules and RuleFunc.
+type Validator interface {
+	Validate(xmlHandler *XmlHandler, options Options) error
+}
+
+// RuleFunc is a Go check of a parsed document that can be added to a Pipeline.
+// A returned StructError or ValidationError is kept, any other error becomes a StructError with the error text as message.
+type RuleFunc func(xmlHandler *XmlHandler) error
+
+// Validate implements the Validator interface.
+func (f RuleFunc) Validate(xmlHandler *XmlHandler, options Options) error {
+	if err := f(xmlHandler); err != nil {
+		return ValidationError{ruleErrors(err)}
+	}
+	return nil
+}
+
+// Returns the errors of a Go rule, errors other than StructError and ValidationError become a StructError of DomainRule.
+func ruleErrors(err error) []StructError {
+	switch err.(type) {
+	case StructError, ValidationError:
+		return structErrors(err)
+	default:
+		return []StructError{{Message: err.Error(), Level: LevelError, Domain: DomainRule}}
+	}
+}
+
+type pipelineStage struct {
+	name      string
+	validator Validator
+}
+
+// Pipeline runs an ordered list of validators against one parsed document.
+// The failures of all stages are aggregated into one ValidationError, the Stage field of each StructError names the stage that reported it.
+type Pipeline struct {
+	stages        []pipelineStage
+	stopOnFailure bool
+}
+
+// NewPipeline creates an empty pipeline, the handlers of its stages are not owned by the pipeline and have to be freed separately.
+func NewPipeline() *Pipeline {
+	return &Pipeline{}
+}
+
+// Add appends a named stage to the pipeline.
+func (p *Pipeline) Add(name string, validator Validator) *Pipeline {
+	p.stages = append(p.stages, pipelineStage{name, validator})
+	return p
+}
+
+// AddFunc appends a named Go rule function to the pipeline.
+func (p *Pipeline) AddFunc(name string, rule func(xmlHandler *XmlHandler) error) *Pipeline {
+	return p.Add(name, RuleFunc(rule))
+}
+
+// StopOnFailure sets whether the pipeline stops after the first stage that reported errors.
+func (p *Pipeline) StopOnFailure(stop bool) *Pipeline {
+	p.stopOnFailure = stop
+	return p
+}
+
+// Validate runs the stages in order against an xmlHandler and returns their failures as one ValidationError.
+// A Libxml2Error stops the pipeline and is returned as is.
+// If an error is returned it is of type Libxml2Error, XmlParserError or ValidationError.
+func (p *Pipeline) Validate(xmlHandler *XmlHandler, options Options) error {
+	if !g.isInitialized() {
+		return Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return XmlParserError{errorMessage: errorMessage{"Xml handler not properly initialized", ErrHandlerNotInitialized}}
+	}
+
+	var ve ValidationError
+	for _, stage := range p.stages {
+		err := stage.validator.Validate(xmlHandler, options)
+		if err == nil {
+			continue
+		}
+		if _, ok := err.(Libxml2Error); ok {
+			return err
+		}
+		for _, se := range structErrors(err) {
+			se.Stage = stage.name
+			ve.Errors = append(ve.Errors, se)
+		}
+		if p.stopOnFailure {
+			break
+		}
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+// ValidateMem parses an xml byte slice once and runs the stages in order against it.
+// If an error is returned it is of type Libxml2Error, XmlParserError or ValidationError.
+func (p *Pipeline) ValidateMem(inXml []byte, options Options) error {
+	if !g.isInitialized() {
+		return Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+	return validateBufWith(inXml, options, func(xmlHandler *XmlHandler) error {
+		return p.Validate(xmlHandler, options)
+	})
+}
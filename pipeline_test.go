@@ -0,0 +1,71 @@
+//go:build apitest
+// +build apitest
+
+package xsdvalidate
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+)
+
+func TestPipeline(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsdHandler, err := NewXsdHandlerUrl("examples/test1_pass.xsd", ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xsdHandler.Free()
+	rngHandler, err := NewRngHandlerUrl("examples/test1_pass.rng", ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rngHandler.Free()
+
+	var seen []string
+	rule := func(name string, err error) func(*XmlHandler) error {
+		return func(xmlHandler *XmlHandler) error {
+			seen = append(seen, name)
+			return err
+		}
+	}
+
+	inXml, err := ioutil.ReadFile("examples/test1_pass.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline := NewPipeline().Add("xsd", xsdHandler).Add("rng", rngHandler).AddFunc("totals", rule("totals", nil))
+	if err := pipeline.ValidateMem(inXml, ParsErrDefault); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	inXml, err = ioutil.ReadFile("examples/test1_fail2.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen = nil
+	pipeline.AddFunc("dates", rule("dates", errors.New("date out of range")))
+	err = pipeline.ValidateMem(inXml, ParsErrDefault)
+	ve, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	stages := map[string]int{}
+	for _, se := range ve.Errors {
+		stages[se.Stage]++
+	}
+	if stages["xsd"] == 0 || stages["rng"] == 0 || stages["totals"] != 0 || stages["dates"] != 1 || len(seen) != 2 {
+		t.Errorf("unexpected stages %v of errors %v", stages, ve.Errors)
+	}
+	if last := ve.Errors[len(ve.Errors)-1]; last.Message != "date out of range" || last.Level != LevelError {
+		t.Errorf("unexpected rule error %#v", last)
+	}
+
+	seen = nil
+	err = pipeline.StopOnFailure(true).ValidateMem(inXml, ParsErrDefault)
+	if ve, ok := err.(ValidationError); !ok || ve.Errors[len(ve.Errors)-1].Stage != "xsd" || len(seen) != 0 {
+		t.Errorf("expected pipeline to stop after the xsd stage, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package xsdvalidate
+
+import "strings"
+
+// AnnotationNamespace is the namespace of schema annotations understood by this package.
+// Mark an xs:element or xs:attribute declaration with sensitive="true" in this namespace to always redact its values in error messages:
+//
+//	<xs:element name="cardNumber" type="xs:string" xv:sensitive="true" xmlns:xv="https://github.com/terminalstatic/go-xsd-validate/annotations"/>
+//
+// The marked declarations are collected by NewXsdHandlerUrl and NewXsdHandlerMem, schema documents that cannot be read
+// are skipped. Declarations are matched by namespace and local name.
+const AnnotationNamespace = "https://github.com/terminalstatic/go-xsd-validate/annotations"
+
+// The replacement for redacted values.
+const redactedValue = "***"
+
+// Masks the document values in the messages of errs if ValidErrRedact is set or the offending element or attribute is marked as sensitive in the schema, xsdHandler may be nil.
+// The value stays available in StructError.Value, suggestions derived from the value are dropped.
+func redactValues(errs []StructError, xsdHandler *XsdHandler, options Options) {
+	for i := range errs {
+		e := &errs[i]
+		if e.Value == "" {
+			continue
+		}
+		if options&ValidErrRedact == 0 {
+			if xsdHandler == nil {
+				continue
+			}
+			if !xsdHandler.sensitive[parseQName(findSubmatch(reElement, e.Message))] &&
+				!xsdHandler.sensitive[parseQName(findSubmatch(reAttribute, e.Message))] {
+				continue
+			}
+		}
+		e.Message = maskValues(e.Message)
+		e.Suggestions = nil
+	}
+}
+
+// Masks every value reValue finds in a message and other quoted occurrences of these values.
+func maskValues(message string) string {
+	var values []string
+	var sb strings.Builder
+	last := 0
+	for _, loc := range reValue.FindAllStringSubmatchIndex(message, -1) {
+		for g := 2; g < len(loc); g += 2 {
+			if loc[g] >= 0 {
+				values = append(values, message[loc[g]:loc[g+1]])
+				sb.WriteString(message[last:loc[g]])
+				sb.WriteString(redactedValue)
+				last = loc[g+1]
+				break
+			}
+		}
+	}
+	sb.WriteString(message[last:])
+	masked := sb.String()
+	for _, v := range values {
+		if v != "" && v != redactedValue {
+			masked = strings.NewReplacer("'"+v+"'", "'"+redactedValue+"'", `"`+v+`"`, `"`+redactedValue+`"`).Replace(masked)
+		}
+	}
+	return masked
+}
@@ -0,0 +1,257 @@
+//go:build apitest
+// +build apitest
+
+package xsdvalidate
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sensitiveXsd = `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:xv="https://github.com/terminalstatic/go-xsd-validate/annotations">
+	<xs:element name="payment">
+		<xs:complexType>
+			<xs:sequence>
+				<xs:element name="card" type="xs:int" xv:sensitive="true"/>
+				<xs:element name="amount" type="xs:int"/>
+			</xs:sequence>
+			<xs:attribute name="pin" type="xs:int" xv:sensitive="true"/>
+		</xs:complexType>
+	</xs:element>
+</xs:schema>`
+
+func checkRedacted(t *testing.T, err error, options Options) {
+	ve, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	for _, se := range ve.Errors {
+		secret := strings.HasPrefix(se.Value, "secret")
+		if se.Value == "" {
+			t.Errorf("expected value in %v", se)
+		}
+		if (secret || options&ValidErrRedact != 0) == strings.Contains(se.Message, se.Value) {
+			t.Errorf("unexpected redaction %q of value %q", se.Message, se.Value)
+		}
+	}
+}
+
+func TestRedactSensitive(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	inXml := []byte(`<payment pin="secret1"><card>secret2</card><amount>plain</amount></payment>`)
+
+	xsdhandler, err := NewXsdHandlerMem([]byte(sensitiveXsd), ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+	checkRedacted(t, xsdhandler.ValidateMem(inXml, ParsErrDefault), ParsErrDefault)
+	checkRedacted(t, xsdhandler.ValidateMem(inXml, ParsErrDefault|ValidErrRedact), ValidErrRedact)
+
+	dir := t.TempDir()
+	main := `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+	<xs:include schemaLocation="payment.xsd"/>
+</xs:schema>`
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.xsd"), []byte(main), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "payment.xsd"), []byte(sensitiveXsd), 0644); err != nil {
+		t.Fatal(err)
+	}
+	urlhandler, err := NewXsdHandlerUrl(filepath.Join(dir, "main.xsd"), ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer urlhandler.Free()
+
+	xmlhandler, err := NewXmlHandlerMem(inXml, ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xmlhandler.Free()
+	checkRedacted(t, urlhandler.Validate(xmlhandler, ValidErrDefault), ValidErrDefault)
+}
+
+func TestRedactSensitiveNamespaces(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	dir := t.TempDir()
+	other := `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:b" elementFormDefault="qualified">
+	<xs:element name="number" type="xs:int"/>
+</xs:schema>`
+	if err := ioutil.WriteFile(filepath.Join(dir, "other.xsd"), []byte(other), 0644); err != nil {
+		t.Fatal(err)
+	}
+	main := `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:xv="https://github.com/terminalstatic/go-xsd-validate/annotations"
+	xmlns:b="urn:b" targetNamespace="urn:a" elementFormDefault="qualified">
+	<xs:import namespace="urn:b" schemaLocation="other.xsd"/>
+	<xs:element name="account">
+		<xs:complexType>
+			<xs:sequence>
+				<xs:element name="number" type="xs:int" xv:sensitive="true"/>
+				<xs:element ref="b:number"/>
+			</xs:sequence>
+		</xs:complexType>
+	</xs:element>
+</xs:schema>`
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.xsd"), []byte(main), 0644); err != nil {
+		t.Fatal(err)
+	}
+	xsdhandler, err := NewXsdHandlerUrl(filepath.Join(dir, "main.xsd"), ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	inXml := []byte(`<account xmlns="urn:a" xmlns:b="urn:b"><number>secret1</number><b:number>plain</b:number></account>`)
+	checkRedacted(t, xsdhandler.ValidateMem(inXml, ParsErrDefault), ParsErrDefault)
+}
+
+func TestSensitiveGroupInclude(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	// the included document only defines a group and is not referenced by the global components of the compiled schema
+	dir := t.TempDir()
+	group := `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:xv="https://github.com/terminalstatic/go-xsd-validate/annotations">
+	<xs:group name="secrets">
+		<xs:sequence>
+			<xs:element name="card" type="xs:int" xv:sensitive="true"/>
+		</xs:sequence>
+	</xs:group>
+</xs:schema>`
+	if err := ioutil.WriteFile(filepath.Join(dir, "group.xsd"), []byte(group), 0644); err != nil {
+		t.Fatal(err)
+	}
+	main := `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+	<xs:include schemaLocation="group.xsd"/>
+	<xs:import namespace="urn:missing" schemaLocation="does_not_exist.xsd"/>
+	<xs:element name="payment">
+		<xs:complexType>
+			<xs:sequence>
+				<xs:group ref="secrets"/>
+				<xs:element name="amount" type="xs:int"/>
+			</xs:sequence>
+		</xs:complexType>
+	</xs:element>
+</xs:schema>`
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.xsd"), []byte(main), 0644); err != nil {
+		t.Fatal(err)
+	}
+	xsdhandler, err := NewXsdHandlerUrl(filepath.Join(dir, "main.xsd"), ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	inXml := []byte(`<payment><card>secret1</card><amount>plain</amount></payment>`)
+	checkRedacted(t, xsdhandler.ValidateMem(inXml, ParsErrDefault), ParsErrDefault)
+}
+
+func TestRedactMessageShapes(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	keyXsd := `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+	<xs:element name="cards">
+		<xs:complexType>
+			<xs:sequence>
+				<xs:element name="card" maxOccurs="unbounded">
+					<xs:complexType>
+						<xs:attribute name="number" type="xs:string"/>
+						<xs:attribute name="pin" type="xs:int"/>
+					</xs:complexType>
+				</xs:element>
+			</xs:sequence>
+		</xs:complexType>
+		<xs:unique name="u">
+			<xs:selector xpath="card"/>
+			<xs:field xpath="@number"/>
+		</xs:unique>
+	</xs:element>
+</xs:schema>`
+	xsdhandler, err := NewXsdHandlerMem([]byte(keyXsd), ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	dtd := `<!ELEMENT a EMPTY><!ATTLIST a t (x|y) #REQUIRED>`
+	dtdhandler, err := NewDtdHandlerMem([]byte(dtd), ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer dtdhandler.Free()
+
+	sct := `<schema xmlns="http://purl.oclc.org/dsdl/schematron">
+	<pattern>
+		<rule context="card">
+			<assert test="string-length(@number) = 4">Card number <value-of select="@number"/> is too long.</assert>
+		</rule>
+	</pattern>
+</schema>`
+	scthandler, err := NewSchematronHandlerMem([]byte(sct), ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer scthandler.Free()
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"duplicate key", xsdhandler.ValidateMem([]byte(`<cards><card number="secret1"/><card number="secret1"/></cards>`), ValidErrRedact)},
+		{"quote", xsdhandler.ValidateMem([]byte(`<cards><card pin="secret'2"/></cards>`), ValidErrRedact)},
+		{"dtd enumeration", dtdhandler.ValidateMem([]byte(`<a t="secret3"/>`), ValidErrRedact)},
+		{"schematron value-of", scthandler.ValidateMem([]byte(`<card number="secret4"/>`), ValidErrRedact)},
+	}
+	for _, tt := range tests {
+		ve, ok := tt.err.(ValidationError)
+		if !ok || len(ve.Errors) == 0 {
+			t.Errorf("%s: expected ValidationError, got %v", tt.name, tt.err)
+			continue
+		}
+		for _, se := range ve.Errors {
+			if strings.Contains(se.Message, "secret") {
+				t.Errorf("%s: value not redacted in %q", tt.name, se.Message)
+			}
+			if !strings.Contains(se.Message, redactedValue) {
+				t.Errorf("%s: expected %q in %q", tt.name, redactedValue, se.Message)
+			}
+		}
+	}
+}
+
+func TestSensitiveUnreadableDocument(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	// libxml2 ignores the include in the annotation, the scan for sensitive declarations cannot read it
+	inXsd := `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:xv="https://github.com/terminalstatic/go-xsd-validate/annotations">
+	<xs:annotation>
+		<xs:appinfo>
+			<xs:include schemaLocation="does_not_exist.xsd"/>
+		</xs:appinfo>
+	</xs:annotation>
+	<xs:element name="card" type="xs:int" xv:sensitive="true"/>
+</xs:schema>`
+	xsdhandler, err := NewXsdHandlerMem([]byte(inXsd), ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+	checkRedacted(t, xsdhandler.ValidateMem([]byte(`<card>secret1</card>`), ParsErrDefault), ParsErrDefault)
+}
@@ -0,0 +1,132 @@
+package xsdvalidate
+
+import (
+	_ "embed"
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// DocumentResult is the validation result of a single document used by the report writers, Name is usually the file path.
+// Err is the error returned by parsing or validation, nil if the document is valid.
+type DocumentResult struct {
+	Name     string
+	Err      error
+	Duration time.Duration
+}
+
+// Measure runs validate, usually a closure calling XsdHandler.Validate or XsdHandler.ValidateMem,
+// and returns the DocumentResult including the time it took.
+func Measure(name string, validate func() error) DocumentResult {
+	start := time.Now()
+	err := validate()
+	return DocumentResult{Name: name, Err: err, Duration: time.Since(start)}
+}
+
+// ReportNamespace is the target namespace of the validation report schema.
+const ReportNamespace = "https://github.com/terminalstatic/go-xsd-validate/report"
+
+// ReportSchema is the xsd describing the documents created by MarshalReport.
+//
+//go:embed validation_report.xsd
+var ReportSchema []byte
+
+// The xml representation of a validation report.
+type xmlReport struct {
+	XMLName xml.Name         `xml:"https://github.com/terminalstatic/go-xsd-validate/report validationReport"`
+	Valid   bool             `xml:"valid,attr"`
+	Type    string           `xml:"type,attr,omitempty"`
+	Errors  []xmlReportError `xml:"error"`
+}
+
+// The xml representation of a StructError.
+type xmlReportError struct {
+	Line        int      `xml:"line,attr"`
+	Column      int      `xml:"column,attr"`
+	Code        int      `xml:"code,attr"`
+	Level       string   `xml:"level,attr"`
+	Category    string   `xml:"category,attr"`
+	Message     string   `xml:"message"`
+	File        string   `xml:"file,omitempty"`
+	Path        string   `xml:"path,omitempty"`
+	Node        string   `xml:"node,omitempty"`
+	Expected    []string `xml:"expected"`
+	Found       string   `xml:"found,omitempty"`
+	Suggestions []string `xml:"suggestion"`
+	Rule        string   `xml:"rule,omitempty"`
+	Assertion   string   `xml:"assertion,omitempty"`
+	Stage       string   `xml:"stage,omitempty"`
+}
+
+// Returns the report type of an error.
+func reportType(err error) string {
+	switch err.(type) {
+	case nil:
+		return ""
+	case ValidationError:
+		return "validation"
+	case XmlParserError:
+		return "xml-parser"
+	case XsdParserError:
+		return "xsd-parser"
+	case RngParserError:
+		return "rng-parser"
+	case SchematronParserError:
+		return "schematron-parser"
+	case DtdParserError:
+		return "dtd-parser"
+	case Libxml2Error:
+		return "libxml2"
+	default:
+		return "error"
+	}
+}
+
+func newXmlReport(err error) xmlReport {
+	r := xmlReport{Valid: err == nil, Type: reportType(err)}
+	for _, e := range structErrors(err) {
+		re := xmlReportError{
+			Line:        e.Line,
+			Column:      e.Column,
+			Code:        e.Code,
+			Level:       levelName(e.Level),
+			Category:    e.Category(),
+			Message:     e.Message,
+			File:        e.File,
+			Path:        e.Path,
+			Node:        e.NodeName,
+			Suggestions: e.Suggestions,
+			Rule:        e.Rule,
+			Assertion:   e.Assertion,
+			Stage:       e.Stage,
+		}
+		for _, q := range e.Expected {
+			re.Expected = append(re.Expected, q.String())
+		}
+		if e.Found != (QName{}) {
+			re.Found = e.Found.String()
+		}
+		r.Errors = append(r.Errors, re)
+	}
+	return r
+}
+
+// MarshalReport encodes an error returned by this package as validationReport xml document (see ReportSchema), including the xml header.
+// A nil error results in a report with valid="true" and no errors.
+func MarshalReport(err error) ([]byte, error) {
+	b, merr := xml.MarshalIndent(newXmlReport(err), "", "\t")
+	if merr != nil {
+		return nil, merr
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+// WriteReport writes the validationReport xml document of an error to w, see MarshalReport.
+func WriteReport(w io.Writer, err error) error {
+	b, merr := MarshalReport(err)
+	if merr != nil {
+		return merr
+	}
+	_, werr := w.Write(b)
+	return werr
+}
@@ -0,0 +1,80 @@
+//go:build apitest
+// +build apitest
+
+package xsdvalidate
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+func TestMarshalReport(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	reportHandler, err := NewXsdHandlerMem(ReportSchema, ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer reportHandler.Free()
+
+	xsdhandler, err := NewXsdHandlerUrl("examples/test1_split.xsd", ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	_, xmlErr := NewXmlHandlerMem([]byte("<a>\n<b></c></a>"), ParsErrVerbose)
+	for _, tc := range []struct {
+		err    error
+		valid  bool
+		typ    string
+		errors []xmlReportError
+	}{
+		{nil, true, "", nil},
+		{
+			xsdhandler.ValidateMem([]byte("<shiporder orderid=\"1\">\n<shipto/></shiporder>"), ParsErrDefault),
+			false,
+			"validation",
+			[]xmlReportError{{
+				Line:     2,
+				Code:     1871,
+				Level:    "error",
+				Category: "schema-validity",
+				Message:  "Element 'shipto': This element is not expected. Expected is ( orderperson ).",
+				Path:     "/shiporder/shipto",
+				Node:     "shipto",
+				Expected: []string{"orderperson"},
+				Found:    "shipto",
+			}},
+		},
+		{
+			xmlErr,
+			false,
+			"xml-parser",
+			[]xmlReportError{{
+				Line:     2,
+				Column:   8,
+				Level:    "fatal",
+				Category: "parser",
+				Message:  "Opening and ending tag mismatch: b line 2 and c",
+			}},
+		},
+	} {
+		report, err := MarshalReport(tc.err)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := reportHandler.ValidateMem(report, ParsErrDefault); err != nil {
+			t.Errorf("invalid report %s: %s", report, err)
+		}
+		var r xmlReport
+		if err := xml.Unmarshal(report, &r); err != nil {
+			t.Fatal(err)
+		}
+		if r.Valid != tc.valid || r.Type != tc.typ || !reflect.DeepEqual(r.Errors, tc.errors) {
+			t.Errorf("unexpected report %s", report)
+		}
+	}
+}
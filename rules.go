@@ -0,0 +1,69 @@
+package xsdvalidate
+
+type selectorRule struct {
+	selector string
+	check    func(node Node) error
+}
+
+// Rules is a Validator for domain checks written in Go, every check is called with the nodes its xpath selector selects.
+// Rules can be added to a Pipeline to run after schema validation on the same parsed document.
+type Rules struct {
+	namespaces map[string]string
+	rules      []selectorRule
+}
+
+// NewRules creates an empty rule set, namespaces maps the prefixes used in the selectors to namespace uris,
+// prefixes declared on the root element can be used without mapping them.
+func NewRules(namespaces map[string]string) *Rules {
+	return &Rules{namespaces: namespaces}
+}
+
+// Add registers a check that is called for every node the xpath selector selects, in document order.
+// A returned StructError or ValidationError is kept, any other error becomes a StructError of DomainRule with the error text as message.
+func (r *Rules) Add(selector string, check func(node Node) error) *Rules {
+	r.rules = append(r.rules, selectorRule{selector, check})
+	return r
+}
+
+// Validate runs the registered checks against an xmlHandler and returns their failures as ValidationError.
+// Line, Path and NodeName of each StructError are those of the node the check failed for if the check did not set them, Rule is the selector.
+// If an error is returned it is of type Libxml2Error, XmlParserError, XPathError or ValidationError.
+func (r *Rules) Validate(xmlHandler *XmlHandler, options Options) error {
+	if !g.isInitialized() {
+		return Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return XmlParserError{errorMessage: errorMessage{"Xml handler not properly initialized", ErrHandlerNotInitialized}}
+	}
+
+	var ve ValidationError
+	for _, rule := range r.rules {
+		nodes, err := selectNodes(xmlHandler, rule.selector, r.namespaces)
+		if err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			err := rule.check(node)
+			if err == nil {
+				continue
+			}
+			for _, se := range ruleErrors(err) {
+				if se.Line == 0 {
+					se.Line = node.Line()
+				}
+				if se.Path == "" {
+					se.Path = node.Path()
+				}
+				if se.NodeName == "" {
+					se.NodeName = node.Name().Local
+				}
+				se.Rule = rule.selector
+				ve.Errors = append(ve.Errors, se)
+			}
+		}
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
@@ -0,0 +1,134 @@
+//go:build apitest
+// +build apitest
+
+package xsdvalidate
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	inXml, err := ioutil.ReadFile("examples/test1_pass.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	xmlHandler, err := NewXmlHandlerMem(inXml, ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xmlHandler.Free()
+
+	rules := NewRules(nil).
+		Add("/shiporder/item/price", func(node Node) error {
+			price, err := strconv.ParseFloat(node.Value(), 64)
+			if err != nil {
+				return err
+			}
+			if price > 10 {
+				return fmt.Errorf("price %s exceeds 10", node.Value())
+			}
+			return nil
+		}).
+		Add("/shiporder/@orderid", func(node Node) error {
+			if node.Value() != "889923" || node.Name().Local != "orderid" {
+				return errors.New("unexpected order id")
+			}
+			return nil
+		}).
+		Add("/shiporder", func(node Node) error {
+			if id, ok := node.Attr("orderid"); !ok || id != "889923" || len(node.Children()) != 4 {
+				return StructError{Message: "unexpected order", Code: 1}
+			}
+			return nil
+		})
+
+	err = rules.Validate(xmlHandler, ValidErrDefault)
+	ve, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(ve.Errors) != 1 {
+		t.Fatalf("expected 1 error, got %v", ve.Errors)
+	}
+	se := ve.Errors[0]
+	if se.Message != "price 10.90 exceeds 10" || se.Line != 14 || se.Path != "/shiporder/item[1]/price" ||
+		se.NodeName != "price" || se.Rule != "/shiporder/item/price" || se.Category() != "rule" {
+		t.Errorf("unexpected error %#v", se)
+	}
+
+	err = NewPipeline().Add("rules", NewRules(nil).Add("/shiporder[", func(Node) error { return nil })).Validate(xmlHandler, ValidErrDefault)
+	if ve, ok := err.(ValidationError); !ok || ve.Errors[0].Stage != "rules" {
+		t.Errorf("expected ValidationError for an invalid selector, got %v", err)
+	}
+}
+
+func TestRulesNamespaces(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xmlHandler, err := NewXmlHandlerMem([]byte(`<o:orders xmlns:o="urn:orders">
+	<o:order total="3"><o:line>1</o:line><o:line>1</o:line></o:order>
+</o:orders>`), ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xmlHandler.Free()
+
+	err = NewRules(map[string]string{"x": "urn:orders"}).Add("//x:order", func(node Node) error {
+		total, _ := node.Attr("total")
+		sum := 0
+		for _, line := range node.Children() {
+			n, _ := strconv.Atoi(line.Value())
+			sum += n
+		}
+		if strconv.Itoa(sum) != total {
+			return fmt.Errorf("total %s does not match %d", total, sum)
+		}
+		return nil
+	}).Validate(xmlHandler, ValidErrDefault)
+	if ve, ok := err.(ValidationError); !ok || ve.Errors[0].Line != 2 || ve.Errors[0].Message != "total 3 does not match 2" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestRulesNodeKinds(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xmlHandler, err := NewXmlHandlerMem([]byte(`<orders>
+	<order>
+		<note>late</note><!-- checked -->
+	</order>
+</orders>`), ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xmlHandler.Free()
+
+	fail := func(node Node) error { return errors.New("failed") }
+	err = NewRules(nil).Add("/", fail).Add("//note/text()", fail).Add("//comment()", fail).Validate(xmlHandler, ValidErrDefault)
+	ve, ok := err.(ValidationError)
+	if !ok || len(ve.Errors) != 3 {
+		t.Fatalf("expected 3 errors, got %v", err)
+	}
+	want := []struct {
+		line int
+		path string
+	}{
+		{0, "/"},
+		{3, "/orders/order/note/text()"},
+		{2, "/orders/order/comment()"},
+	}
+	for i, w := range want {
+		if se := ve.Errors[i]; se.Line != w.line || se.Path != w.path || se.NodeName != "" {
+			t.Errorf("unexpected error %#v, want %+v", se, w)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package xsdvalidate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationUri string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	Id               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleId    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	Uri string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// Returns the SARIF rule id of a StructError, derived from the libxml2 error code.
+func sarifRuleId(e StructError) string {
+	return fmt.Sprintf("XML%04d", e.Code)
+}
+
+// Returns the SARIF level of a StructError.
+func sarifLevel(e StructError) string {
+	if e.Level == LevelWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// MarshalSarif encodes the results of one or more documents as SARIF 2.1.0 log with a single run.
+// Every StructError becomes a SARIF result, rule ids are the libxml2 error codes prefixed with XML, e.g. XML1871.
+// The artifact of a result is the File of the StructError, like an XIncluded file, or else the name of the document.
+func MarshalSarif(results []DocumentResult) ([]byte, error) {
+	run := sarifRun{
+		Tool: sarifTool{sarifDriver{
+			Name:           "go-xsd-validate",
+			InformationUri: "https://github.com/terminalstatic/go-xsd-validate",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	ruleIndex := make(map[string]int)
+	for _, dr := range results {
+		for _, e := range structErrors(dr.Err) {
+			id := sarifRuleId(e)
+			idx, ok := ruleIndex[id]
+			if !ok {
+				idx = len(run.Tool.Driver.Rules)
+				ruleIndex[id] = idx
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+					Id:               id,
+					Name:             e.Category(),
+					ShortDescription: sarifMessage{fmt.Sprintf("libxml2 %s error %d", e.Category(), e.Code)},
+				})
+			}
+			artifact := dr.Name
+			if e.File != "" {
+				artifact = e.File
+			}
+			loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{filepath.ToSlash(artifact)},
+			}}
+			if e.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{StartLine: e.Line, StartColumn: e.Column}
+			}
+			if e.Path != "" {
+				loc.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: e.Path, Kind: "element"}}
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleId:    id,
+				RuleIndex: idx,
+				Level:     sarifLevel(e),
+				Message:   sarifMessage{e.Message},
+				Locations: []sarifLocation{loc},
+			})
+		}
+	}
+	return json.MarshalIndent(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+}
+
+// WriteSarif writes the SARIF 2.1.0 log of the results to w, see MarshalSarif.
+func WriteSarif(w io.Writer, results []DocumentResult) error {
+	b, err := MarshalSarif(results)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
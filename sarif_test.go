@@ -0,0 +1,80 @@
+//go:build apitest
+// +build apitest
+
+package xsdvalidate
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+func TestMarshalSarif(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsdhandler, err := NewXsdHandlerUrl("examples/test1_split.xsd", ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	var results []DocumentResult
+	for _, name := range []string{"examples/test1_pass.xml", "examples/test1_fail2.xml", "examples/test1_fail1.xml"} {
+		inXml, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, DocumentResult{Name: name, Err: xsdhandler.ValidateMem(inXml, ParsErrVerbose)})
+	}
+
+	b, err := MarshalSarif(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(b, &log); err != nil {
+		t.Fatal(err)
+	}
+	res := log.Runs[0].Results
+	if len(res) != 3 {
+		t.Fatalf("expected 3 results, got %s", b)
+	}
+	if res[0].RuleId != "XML1871" || res[0].Locations[0].PhysicalLocation.ArtifactLocation.Uri != "examples/test1_fail2.xml" ||
+		res[0].Locations[0].PhysicalLocation.Region.StartLine != 3 {
+		t.Errorf("unexpected result %+v", res[0])
+	}
+	if res[1].Locations[0].PhysicalLocation.Region.StartLine != 3 || res[1].Level != "error" {
+		t.Errorf("unexpected result %+v", res[1])
+	}
+}
+
+func TestMarshalSarifXInclude(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsdhandler, err := NewXsdHandlerUrl("examples/test1_pass.xsd", ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+	xmlhandler, err := NewXmlHandlerUrl("examples/test1_xinclude.xml", ParsErrDefault|ParsXInclude)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xmlhandler.Free()
+
+	b, err := MarshalSarif([]DocumentResult{{Name: "examples/test1_xinclude.xml", Err: xsdhandler.Validate(xmlhandler, ValidErrDefault)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(b, &log); err != nil {
+		t.Fatal(err)
+	}
+	res := log.Runs[0].Results
+	if len(res) != 1 || res[0].Locations[0].PhysicalLocation.ArtifactLocation.Uri != "examples/test1_xinclude_item.xml" ||
+		res[0].Locations[0].PhysicalLocation.Region.StartLine != 6 {
+		t.Errorf("expected result in the included file, got %s", b)
+	}
+}
@@ -0,0 +1,115 @@
+package xsdvalidate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Snippet renders the errors of a ValidationError, XmlParserError or XsdParserError together with the offending lines of src,
+// the original input, and marks the error position rustc-style. contextLines is the number of lines shown before and after each offending line.
+// If the error has no column, the start tag of the offending node or else the whole line is marked.
+// Errors with a File, like those of XIncluded files, are shown without source lines, see SnippetFile.
+func Snippet(src []byte, err error, contextLines int) string {
+	return SnippetFile(src, "", err, contextLines)
+}
+
+// SnippetFile renders the errors like Snippet, file is the file or URL src was read from.
+// Errors of other files, like those of XIncluded files, are shown with their file and line but without source lines.
+func SnippetFile(src []byte, file string, err error, contextLines int) string {
+	lines := strings.Split(strings.Replace(string(src), "\r\n", "\n", -1), "\n")
+	var sb strings.Builder
+	for i, e := range structErrors(err) {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		if e.Code != 0 {
+			fmt.Fprintf(&sb, "%s[%d]: %s\n", levelName(e.Level), e.Code, e.Message)
+		} else {
+			fmt.Fprintf(&sb, "%s: %s\n", levelName(e.Level), e.Message)
+		}
+		if e.File != "" && e.File != file {
+			if e.Line > 0 {
+				fmt.Fprintf(&sb, "--> %s:%d\n", e.File, e.Line)
+			}
+			continue
+		}
+		if e.Line < 1 || e.Line > len(lines) {
+			continue
+		}
+
+		first, last := e.Line-contextLines, e.Line+contextLines
+		if first < 1 {
+			first = 1
+		}
+		if last > len(lines) {
+			last = len(lines)
+		}
+		width := len(fmt.Sprint(last))
+		gutter := strings.Repeat(" ", width)
+
+		if e.Column > 0 {
+			fmt.Fprintf(&sb, "%s--> line %d, column %d\n", gutter, e.Line, e.Column)
+		} else {
+			fmt.Fprintf(&sb, "%s--> line %d\n", gutter, e.Line)
+		}
+		fmt.Fprintf(&sb, "%s |\n", gutter)
+		for n := first; n <= last; n++ {
+			fmt.Fprintf(&sb, "%*d | %s\n", width, n, lines[n-1])
+			if n == e.Line {
+				fmt.Fprintf(&sb, "%s | %s\n", gutter, marker(lines[n-1], e))
+			}
+		}
+		fmt.Fprintf(&sb, "%s |\n", gutter)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// Returns the caret line for the offending line of an error.
+func marker(line string, e StructError) string {
+	start, length := 0, 0
+	if e.Column > 0 {
+		start, length = e.Column-1, 1
+		if start > len(line) {
+			start = len(line)
+		}
+	} else if loc := startTagIndex(line, e.NodeName); loc != nil {
+		start, length = loc[0], loc[1]-loc[0]
+	} else {
+		trimmed := strings.TrimLeft(line, " \t")
+		start, length = len(line)-len(trimmed), len(strings.TrimRight(trimmed, " \t"))
+	}
+	if length < 1 {
+		length = 1
+	}
+	// Keep tabs so the caret lines up with the source line.
+	prefix := []byte(line[:start])
+	for i, c := range prefix {
+		if c != '\t' {
+			prefix[i] = ' '
+		}
+	}
+	return string(prefix) + strings.Repeat("^", length)
+}
+
+// Returns the location of the start tag of an element named name in line, with or without namespace prefix.
+func startTagIndex(line string, name string) []int {
+	if name == "" {
+		return nil
+	}
+	for i := strings.Index(line, "<"); i >= 0; {
+		end := len(line)
+		if n := strings.IndexAny(line[i+1:], " \t\r\n/><"); n >= 0 {
+			end = i + 1 + n
+		}
+		tag := line[i+1 : end]
+		if tag == name || (strings.HasSuffix(tag, ":"+name) && strings.Index(tag, ":") == len(tag)-len(name)-1) {
+			return []int{i, end}
+		}
+		n := strings.Index(line[i+1:], "<")
+		if n < 0 {
+			break
+		}
+		i += 1 + n
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+//go:build apitest
+// +build apitest
+
+package xsdvalidate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSnippetValidationError(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsdhandler, err := NewXsdHandlerUrl("examples/test1_split.xsd", ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	inXml := []byte("<?xml version=\"1.0\"?>\n<shiporder orderid=\"1\">\n\t<shipto>\n\t</shipto>\n</shiporder>")
+	want := `error[1871]: Element 'shipto': This element is not expected. Expected is ( orderperson ).
+ --> line 3
+  |
+2 | <shiporder orderid="1">
+3 | 	<shipto>
+  | 	^^^^^^^
+4 | 	</shipto>
+  |`
+	if got := Snippet(inXml, xsdhandler.ValidateMem(inXml, ParsErrDefault), 1); got != want {
+		t.Errorf("unexpected snippet:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSnippetXmlParserError(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	inXml := []byte("<a>\n<b></c></a>")
+	_, err := NewXmlHandlerMem(inXml, ParsErrVerbose)
+	want := `fatal: Opening and ending tag mismatch: b line 2 and c
+ --> line 2, column 8
+  |
+1 | <a>
+2 | <b></c></a>
+  |        ^
+  |`
+	if got := Snippet(inXml, err, 2); got != want {
+		t.Errorf("unexpected snippet:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSnippetLongLine(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	// libxml2 truncates the context of the error, the column is the one libxml2 reports
+	inXml := []byte("<a>" + strings.Repeat("x", 100) + "</b></a>")
+	_, err := NewXmlHandlerMem(inXml, ParsErrVerbose)
+	if errs := structErrors(err); len(errs) == 0 || errs[0].Line != 1 || errs[0].Column != 108 {
+		t.Fatalf("expected an error in line 1, column 108, got %#v", errs)
+	}
+	if got := Snippet(inXml, err, 0); !strings.Contains(got, "--> line 1, column 108\n") ||
+		!strings.Contains(got, " | "+strings.Repeat(" ", 107)+"^\n") {
+		t.Errorf("unexpected snippet:\n%s", got)
+	}
+
+	// the columns are kept when the document is parsed for validation
+	xsdhandler, err := NewXsdHandlerUrl("examples/test1_pass.xsd", ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+	if errs := structErrors(xsdhandler.ValidateMem(inXml, ParsErrVerbose)); len(errs) == 0 || errs[0].Column != 108 {
+		t.Errorf("expected an error in column 108, got %#v", errs)
+	}
+}
+
+func TestStartTagIndex(t *testing.T) {
+	tests := []struct {
+		line string
+		name string
+		want []int
+	}{
+		{`<a><item>`, "item", []int{3, 8}},
+		{`<a><ns:item/>`, "item", []int{3, 11}},
+		{`<items><item>`, "item", []int{7, 12}},
+		{`</item><item`, "item", []int{7, 12}},
+		{`<a:b:item>`, "item", nil},
+		{`<itemx>`, "item", nil},
+	}
+	for _, tc := range tests {
+		if got := startTagIndex(tc.line, tc.name); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("startTagIndex(%q, %q) = %v, want %v", tc.line, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSnippetFileXInclude(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsdhandler, err := NewXsdHandlerUrl("examples/test1_pass.xsd", ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	// the error is in the included file, src is not rendered for it
+	inXml := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<shiporder orderid="889923" xmlns:xi="http://www.w3.org/2001/XInclude">
+	<orderperson>John Smith</orderperson>
+	<shipto>
+		<name>Ola Nordmann</name>
+		<address>Langgt 23</address>
+		<city>4000 Stavanger</city>
+		<country>Norway</country>
+	</shipto>
+	<xi:include href="test1_xinclude_item.xml"/>
+</shiporder>`)
+	xmlhandler, err := NewXmlHandlerMemBase(inXml, "examples/test1_xinclude.xml", ParsErrDefault|ParsXInclude)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xmlhandler.Free()
+	err = xsdhandler.Validate(xmlhandler, ValidErrDefault)
+
+	got := SnippetFile(inXml, "examples/test1_xinclude.xml", err, 1)
+	if !strings.HasSuffix(got, "--> examples/test1_xinclude_item.xml:6") || strings.Contains(got, " | ") {
+		t.Errorf("unexpected snippet:\n%s", got)
+	}
+	if got := Snippet(inXml, err, 1); strings.Contains(got, " | ") {
+		t.Errorf("unexpected snippet:\n%s", got)
+	}
+}
@@ -0,0 +1,126 @@
+package xsdvalidate
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// The maximum number of suggestions attached to a StructError.
+const maxSuggestions = 3
+
+var (
+	reEnumeration = regexp.MustCompile(`\[facet 'enumeration'\] The value '(.*)' is not an element of the set \{(.*)\}\.`)
+	reNoRoot      = regexp.MustCompile(`^Element '([^']+)': No matching global declaration available for the validation root\.`)
+)
+
+// Attaches "did you mean" suggestions to enumeration and element name errors.
+// Enumeration values are taken from the enumeration facet of the xsdHandler's schema whose value set libxml2 reports,
+// expected elements from the error message libxml2 creates from the compiled schema,
+// misspelled root elements are matched against the global element declarations of the xsdHandler's schema.
+func addSuggestions(errs []StructError, xsdHandler *XsdHandler) {
+	var globals []string
+	var enumerations [][]string
+	for i := range errs {
+		e := &errs[i]
+		if m := reEnumeration.FindStringSubmatch(e.Message); m != nil {
+			if enumerations == nil {
+				enumerations = schemaEnumerations(xsdHandler)
+			}
+			e.Suggestions = closestMatches(m[1], enumerationValues(m[2], enumerations))
+			continue
+		}
+		if e.Found != (QName{}) && len(e.Expected) > 0 {
+			names := make([]string, len(e.Expected))
+			for j, q := range e.Expected {
+				names[j] = q.String()
+			}
+			e.Suggestions = closestMatches(e.Found.String(), names)
+			continue
+		}
+		if m := reNoRoot.FindStringSubmatch(e.Message); m != nil {
+			if globals == nil {
+				for _, q := range schemaGlobalElements(xsdHandler) {
+					globals = append(globals, q.String())
+				}
+			}
+			e.Suggestions = closestMatches(m[1], globals)
+		}
+	}
+}
+
+// Returns the enumeration values of the schema libxml2 reports as set, it quotes the values as 'a', 'b' without escaping quotes in them.
+// The values are compared whitespace collapsed as libxml2 reports those of token types.
+func enumerationValues(set string, enumerations [][]string) []string {
+	for _, values := range enumerations {
+		if "'"+strings.Join(values, "', '")+"'" == set {
+			return values
+		}
+		collapsed := make([]string, len(values))
+		for i, v := range values {
+			collapsed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		if "'"+strings.Join(collapsed, "', '")+"'" == set {
+			return collapsed
+		}
+	}
+	return nil
+}
+
+// Returns up to maxSuggestions candidates within an edit distance relative to the length of value, closest first.
+func closestMatches(value string, candidates []string) []string {
+	type match struct {
+		candidate string
+		distance  int
+	}
+	maxDistance := len([]rune(value))/3 + 1
+	var matches []match
+	for _, c := range candidates {
+		if c == value {
+			continue
+		}
+		if d := editDistance(strings.ToLower(value), strings.ToLower(c)); d <= maxDistance {
+			matches = append(matches, match{c, d})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].candidate < matches[j].candidate
+	})
+	var suggestions []string
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, matches[i].candidate)
+	}
+	return suggestions
+}
+
+// Levenshtein distance of two strings.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(minInt(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,129 @@
+package xsdvalidate
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// NewDtdHandlerUrl creates a DTD handler struct.
+// Always use Free() method when done using this handler or memory will be leaking.
+// If an error is returned it can be of type Libxml2Error or DtdParserError.
+// The go garbage collector will not collect the allocated resources.
+func NewDtdHandlerUrl(url string, options Options) (*DtdHandler, error) {
+	g.Lock()
+	defer g.Unlock()
+	if !g.isInitialized() {
+		return nil, Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+	dPtr, err := parseUrlDtd(url, options)
+	return &DtdHandler{dPtr}, err
+}
+
+// NewDtdHandlerMem creates a DTD handler struct.
+// Always use Free() method when done using this handler or memory will leak.
+// If an error is returned it can be of type Libxml2Error or DtdParserError.
+// The go garbage collector will not collect the allocated resources.
+func NewDtdHandlerMem(inDtd []byte, options Options) (*DtdHandler, error) {
+	g.Lock()
+	defer g.Unlock()
+	if !g.isInitialized() {
+		return nil, Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+	dPtr, err := parseMemDtd(inDtd, options)
+	return &DtdHandler{dPtr}, err
+}
+
+// Validate validates an xmlHandler against a dtdHandler and returns a ValidationError, a DOCTYPE of the document is ignored.
+// With ValidErrRedact document values are masked in the error messages.
+// If an error is returned it is of type Libxml2Error, DtdParserError, XmlParserError or ValidationError.
+// Both xmlHandler and dtdHandler have to be created first.
+func (dtdHandler *DtdHandler) Validate(xmlHandler *XmlHandler, options Options) error {
+	if !g.isInitialized() {
+		return Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+
+	if dtdHandler == nil || dtdHandler.dtdPtr == nil {
+		return DtdParserError{errorMessage{"Dtd handler not properly initialized", ErrHandlerNotInitialized}}
+	}
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return XmlParserError{errorMessage: errorMessage{"Xml handler not properly initialized", ErrHandlerNotInitialized}}
+	}
+	return validateWithDtd(xmlHandler, dtdHandler.dtdPtr, nil, options)
+}
+
+// ValidateMem validates an xml byte slice against a dtdHandler.
+// If an error is returned it can be of type Libxml2Error, DtdParserError, XmlParserError or ValidationError.
+// The dtdHandler has to be created first.
+func (dtdHandler *DtdHandler) ValidateMem(inXml []byte, options Options) error {
+	if !g.isInitialized() {
+		return Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+	if dtdHandler == nil || dtdHandler.dtdPtr == nil {
+		return DtdParserError{errorMessage{"Dtd handler not properly initialized", ErrHandlerNotInitialized}}
+	}
+	return validateBufWith(inXml, options, func(xmlHandler *XmlHandler) error {
+		return validateWithDtd(xmlHandler, dtdHandler.dtdPtr, nil, options)
+	})
+}
+
+// Free frees the wrapped dtdPtr, call this when this handler is not needed anymore.
+func (dtdHandler *DtdHandler) Free() {
+	freeDtdPtr(dtdHandler)
+}
+
+// ValidateDoctype validates an xmlHandler against the DOCTYPE of the document, only the internal subset is used.
+// An external subset the DOCTYPE declares is not loaded, the system identifier of an untrusted document can name any local file or URL.
+// Elements and attributes only declared in the external subset are reported as undeclared, see ValidateDoctypeExternal.
+// A document without DOCTYPE is reported as ValidationError.
+// If an error is returned it is of type Libxml2Error, XmlParserError or ValidationError.
+func (xmlHandler *XmlHandler) ValidateDoctype(options Options) error {
+	if !g.isInitialized() {
+		return Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return XmlParserError{errorMessage: errorMessage{"Xml handler not properly initialized", ErrHandlerNotInitialized}}
+	}
+	return validateWithDtd(xmlHandler, nil, nil, options)
+}
+
+// ValidateDoctypeExternal validates an xmlHandler against the DOCTYPE of the document like ValidateDoctype
+// and loads the external subset the DOCTYPE declares from the directory dir.
+// The system identifier has to be a relative path that stays inside of dir, other system identifiers like absolute paths
+// or URLs are not loaded and reported as DtdParserError. Only pass a dir whose files may be read by the documents.
+// If an error is returned it is of type Libxml2Error, XmlParserError, DtdParserError or ValidationError.
+func (xmlHandler *XmlHandler) ValidateDoctypeExternal(dir string, options Options) error {
+	if !g.isInitialized() {
+		return Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return XmlParserError{errorMessage: errorMessage{"Xml handler not properly initialized", ErrHandlerNotInitialized}}
+	}
+	doctype, ok := docDoctype(xmlHandler)
+	if !ok || doctype.SystemID == "" {
+		return validateWithDtd(xmlHandler, nil, nil, options)
+	}
+	path, ok := externalSubsetPath(dir, doctype.SystemID)
+	if !ok {
+		return DtdParserError{errorMessage{Message: "External subset '" + doctype.SystemID + "' is not a relative path inside '" + dir + "'"}}
+	}
+	dPtr, err := parseUrlDtd(path, options)
+	if err != nil {
+		return err
+	}
+	defer freeDtdPtr(&DtdHandler{dPtr})
+	return validateWithDtd(xmlHandler, nil, dPtr, options)
+}
+
+// Resolves the system identifier of an external subset against dir, it has to be a relative path inside of dir.
+// Identifiers with a scheme or percent-encoding are rejected, libxml2 retries unescaped names of files it cannot open.
+func externalSubsetPath(dir, systemID string) (string, bool) {
+	if strings.ContainsAny(systemID, ":%") || strings.HasPrefix(systemID, "/") || filepath.IsAbs(systemID) {
+		return "", false
+	}
+	path := filepath.Join(dir, filepath.FromSlash(systemID))
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return path, true
+}
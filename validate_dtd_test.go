@@ -0,0 +1,137 @@
+//go:build apitest
+// +build apitest
+
+package xsdvalidate
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateWithDtdHandler(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	dtdHandler, err := NewDtdHandlerUrl("examples/test1_pass.dtd", ParsErrVerbose)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dtdHandler.Free()
+
+	inXml, err := ioutil.ReadFile("examples/test1_pass.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dtdHandler.ValidateMem(inXml, ParsErrDefault); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	inXml, err = ioutil.ReadFile("examples/test1_fail2.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	xmlHandler, err := NewXmlHandlerMem(inXml, ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xmlHandler.Free()
+	err = dtdHandler.Validate(xmlHandler, ValidErrDefault)
+	ve, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(ve.Errors) != 1 || ve.Errors[0].Line != 2 || ve.Errors[0].Category() != "dtd" || ve.Errors[0].NodeName != "shiporder" {
+		t.Errorf("unexpected errors %v", ve.Errors)
+	}
+}
+
+func TestNewDtdHandlerMemFail(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	dtdHandler, err := NewDtdHandlerMem([]byte(`<!ELEMENT shiporder (orderperson`), ParsErrDefault)
+	defer dtdHandler.Free()
+	if _, ok := err.(DtdParserError); !ok {
+		t.Fatalf("expected DtdParserError, got %v", err)
+	}
+}
+
+func TestValidateDoctype(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	doc := `<?xml version="1.0"?>
+<!DOCTYPE note [
+	<!ELEMENT note (to, body)>
+	<!ELEMENT to (#PCDATA)>
+	<!ELEMENT body (#PCDATA)>
+]>
+`
+	xmlHandler, err := NewXmlHandlerMem([]byte(doc+`<note><to>Tove</to><body>Hi</body></note>`), ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xmlHandler.Free()
+	if err := xmlHandler.ValidateDoctype(ValidErrDefault); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	failHandler, err := NewXmlHandlerMem([]byte(doc+`<note>
+	<body>Hi</body>
+</note>`), ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer failHandler.Free()
+	err = failHandler.ValidateDoctype(ValidErrDefault)
+	if ve, ok := err.(ValidationError); !ok || ve.Errors[0].Line != 7 {
+		t.Errorf("expected ValidationError on line 7, got %v", err)
+	}
+
+	noDoctype, err := NewXmlHandlerMem([]byte(`<note/>`), ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer noDoctype.Free()
+	if _, ok := noDoctype.ValidateDoctype(ValidErrDefault).(ValidationError); !ok {
+		t.Error("expected ValidationError for a document without DOCTYPE")
+	}
+}
+
+func TestValidateDoctypeExternal(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	dir := t.TempDir()
+	dtd := `<!ELEMENT note (to, body)>
+<!ELEMENT to (#PCDATA)>
+<!ELEMENT body (#PCDATA)>`
+	if err := ioutil.WriteFile(filepath.Join(dir, "note.dtd"), []byte(dtd), 0644); err != nil {
+		t.Fatal(err)
+	}
+	xmlHandler, err := NewXmlHandlerMem([]byte(`<!DOCTYPE note SYSTEM "note.dtd"><note><to>Tove</to><body>Hi</body></note>`), ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xmlHandler.Free()
+
+	// the external subset is only loaded on request
+	if _, ok := xmlHandler.ValidateDoctype(ValidErrDefault).(ValidationError); !ok {
+		t.Error("expected ValidationError without the external subset")
+	}
+	if err := xmlHandler.ValidateDoctypeExternal(dir, ValidErrDefault); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	for _, systemID := range []string{"../note.dtd", "/etc/passwd", "file:///etc/passwd", "%2e%2e/note.dtd"} {
+		h, err := NewXmlHandlerMem([]byte(`<!DOCTYPE note SYSTEM "`+systemID+`"><note/>`), ParsErrDefault)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := h.ValidateDoctypeExternal(dir, ValidErrDefault).(DtdParserError); !ok {
+			t.Errorf("expected DtdParserError for system identifier %s", systemID)
+		}
+		h.Free()
+	}
+}
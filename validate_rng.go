@@ -0,0 +1,67 @@
+package xsdvalidate
+
+// NewRngHandlerUrl creates a RelaxNG handler struct.
+// Always use Free() method when done using this handler or memory will be leaking.
+// If an error is returned it can be of type Libxml2Error or RngParserError.
+// The go garbage collector will not collect the allocated resources.
+func NewRngHandlerUrl(url string, options Options) (*RngHandler, error) {
+	g.Lock()
+	defer g.Unlock()
+	if !g.isInitialized() {
+		return nil, Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+	rPtr, err := parseUrlRng(url, options)
+	return &RngHandler{rPtr}, err
+}
+
+// NewRngHandlerMem creates a RelaxNG handler struct.
+// Always use Free() method when done using this handler or memory will leak.
+// If an error is returned it can be of type Libxml2Error or RngParserError.
+// The go garbage collector will not collect the allocated resources.
+func NewRngHandlerMem(inRng []byte, options Options) (*RngHandler, error) {
+	g.Lock()
+	defer g.Unlock()
+	if !g.isInitialized() {
+		return nil, Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+	rPtr, err := parseMemRng(inRng, options)
+	return &RngHandler{rPtr}, err
+}
+
+// Validate validates an xmlHandler against a rngHandler and returns a ValidationError.
+// With ValidErrRedact document values are masked in the error messages.
+// If an error is returned it is of type Libxml2Error, RngParserError, XmlParserError or ValidationError.
+// Both xmlHandler and rngHandler have to be created first.
+func (rngHandler *RngHandler) Validate(xmlHandler *XmlHandler, options Options) error {
+	if !g.isInitialized() {
+		return Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+
+	if rngHandler == nil || rngHandler.rngPtr == nil {
+		return RngParserError{errorMessage{"Rng handler not properly initialized", ErrHandlerNotInitialized}}
+	}
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return XmlParserError{errorMessage: errorMessage{"Xml handler not properly initialized", ErrHandlerNotInitialized}}
+	}
+	return validateWithRng(xmlHandler, rngHandler, options)
+}
+
+// ValidateMem validates an xml byte slice against a rngHandler.
+// If an error is returned it can be of type Libxml2Error, RngParserError, XmlParserError or ValidationError.
+// The rngHandler has to be created first.
+func (rngHandler *RngHandler) ValidateMem(inXml []byte, options Options) error {
+	if !g.isInitialized() {
+		return Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+	if rngHandler == nil || rngHandler.rngPtr == nil {
+		return RngParserError{errorMessage{"Rng handler not properly initialized", ErrHandlerNotInitialized}}
+	}
+	return validateBufWith(inXml, options, func(xmlHandler *XmlHandler) error {
+		return validateWithRng(xmlHandler, rngHandler, options)
+	})
+}
+
+// Free frees the wrapped rngPtr, call this when this handler is not needed anymore.
+func (rngHandler *RngHandler) Free() {
+	freeRngPtr(rngHandler)
+}
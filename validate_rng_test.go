@@ -0,0 +1,64 @@
+//go:build apitest
+// +build apitest
+
+package xsdvalidate
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestValidateWithRngHandler(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	rngHandler, err := NewRngHandlerUrl("examples/test1_pass.rng", ParsErrVerbose)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rngHandler.Free()
+
+	inXml, err := ioutil.ReadFile("examples/test1_pass.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rngHandler.ValidateMem(inXml, ParsErrDefault); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	xmlHandler, err := NewXmlHandlerMem(inXml, ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xmlHandler.Free()
+	if err := rngHandler.Validate(xmlHandler, ValidErrDefault); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	inXml, err = ioutil.ReadFile("examples/test1_fail2.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rngHandler.ValidateMem(inXml, ParsErrDefault)
+	ve, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(ve.Errors) == 0 || ve.Errors[0].Line != 3 || ve.Errors[0].Domain != DomainRelaxNGValid {
+		t.Errorf("unexpected errors %v", ve.Errors)
+	}
+}
+
+func TestNewRngHandlerMemFail(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	rngHandler, err := NewRngHandlerMem([]byte(`<element xmlns="http://relaxng.org/ns/structure/1.0"/>`), ParsErrVerbose)
+	defer rngHandler.Free()
+	if _, ok := err.(RngParserError); !ok {
+		t.Fatalf("expected RngParserError, got %v", err)
+	}
+	if err.Error() == "" {
+		t.Error("expected error message")
+	}
+}
@@ -0,0 +1,73 @@
+package xsdvalidate
+
+// NewSchematronHandlerUrl creates a schematron handler struct from an ISO Schematron schema, the rule context, test, let and value-of expressions are checked when parsing.
+// Supported are ns, pattern, rule, assert, report, name, value-of, let on schema, pattern and rule level and abstract rules used with extends.
+// Schemas with phase, include, diagnostics or abstract patterns are rejected with a SchematronParserError.
+// Always use Free() method when done using this handler or memory will be leaking.
+// If an error is returned it can be of type Libxml2Error or SchematronParserError.
+// The go garbage collector will not collect the allocated resources.
+func NewSchematronHandlerUrl(url string, options Options) (*SchematronHandler, error) {
+	g.Lock()
+	defer g.Unlock()
+	if !g.isInitialized() {
+		return nil, Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+	sPtr, err := parseUrlSct(url, options)
+	return &SchematronHandler{sPtr}, err
+}
+
+// NewSchematronHandlerMem creates a schematron handler struct from an ISO Schematron schema, the rule context, test, let and value-of expressions are checked when parsing.
+// Supported are ns, pattern, rule, assert, report, name, value-of, let on schema, pattern and rule level and abstract rules used with extends.
+// Schemas with phase, include, diagnostics or abstract patterns are rejected with a SchematronParserError.
+// Always use Free() method when done using this handler or memory will leak.
+// If an error is returned it can be of type Libxml2Error or SchematronParserError.
+// The go garbage collector will not collect the allocated resources.
+func NewSchematronHandlerMem(inSct []byte, options Options) (*SchematronHandler, error) {
+	g.Lock()
+	defer g.Unlock()
+	if !g.isInitialized() {
+		return nil, Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+	sPtr, err := parseMemSct(inSct, options)
+	return &SchematronHandler{sPtr}, err
+}
+
+// Validate runs the rules of a sctHandler against an xmlHandler, failed asserts and successful reports are returned as ValidationError.
+// Each StructError carries the rule context, the assertion id and the path of the node the rule fired on.
+// Within a pattern a node is only checked by the first rule matching it, as required by ISO Schematron.
+// With ValidErrRedact the value-of expansions are masked in the messages of failed asserts and successful reports.
+// If an error is returned it is of type Libxml2Error, SchematronParserError, XmlParserError or ValidationError.
+// Both xmlHandler and sctHandler have to be created first.
+func (sctHandler *SchematronHandler) Validate(xmlHandler *XmlHandler, options Options) error {
+	if !g.isInitialized() {
+		return Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+
+	if sctHandler == nil || sctHandler.sctPtr == nil {
+		return SchematronParserError{errorMessage{"Schematron handler not properly initialized", ErrHandlerNotInitialized}}
+	}
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return XmlParserError{errorMessage: errorMessage{"Xml handler not properly initialized", ErrHandlerNotInitialized}}
+	}
+	return validateWithSct(xmlHandler, sctHandler, options)
+}
+
+// ValidateMem runs the rules of a sctHandler against an xml byte slice.
+// If an error is returned it can be of type Libxml2Error, SchematronParserError, XmlParserError or ValidationError.
+// The sctHandler has to be created first.
+func (sctHandler *SchematronHandler) ValidateMem(inXml []byte, options Options) error {
+	if !g.isInitialized() {
+		return Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+	if sctHandler == nil || sctHandler.sctPtr == nil {
+		return SchematronParserError{errorMessage{"Schematron handler not properly initialized", ErrHandlerNotInitialized}}
+	}
+	return validateBufWith(inXml, options, func(xmlHandler *XmlHandler) error {
+		return validateWithSct(xmlHandler, sctHandler, options)
+	})
+}
+
+// Free frees the wrapped schematron document, call this when this handler is not needed anymore.
+func (sctHandler *SchematronHandler) Free() {
+	freeSctPtr(sctHandler)
+}
@@ -0,0 +1,215 @@
+//go:build apitest
+// +build apitest
+
+package xsdvalidate
+
+import (
+	"testing"
+)
+
+const orderSchematron = `<?xml version="1.0" encoding="UTF-8"?>
+<sch:schema xmlns:sch="http://purl.oclc.org/dsdl/schematron">
+	<sch:ns prefix="o" uri="urn:orders"/>
+	<sch:pattern>
+		<sch:rule context="o:order[@type='express']">
+			<sch:assert id="express-date" test="o:deliveryDate">Express order <sch:value-of select="@id"/>
+				needs a deliveryDate.</sch:assert>
+		</sch:rule>
+		<sch:rule context="o:order">
+			<sch:report id="no-type" test="not(@type)"><sch:name/> without type</sch:report>
+		</sch:rule>
+	</sch:pattern>
+</sch:schema>`
+
+func TestValidateWithSchematronHandler(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	sctHandler, err := NewSchematronHandlerMem([]byte(orderSchematron), ParsErrVerbose)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sctHandler.Free()
+
+	inXml := []byte(`<orders xmlns="urn:orders">
+	<order id="1" type="express"><deliveryDate>2026-01-01</deliveryDate></order>
+	<order id="2" type="standard"/>
+</orders>`)
+	if err := sctHandler.ValidateMem(inXml, ParsErrDefault); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	inXml = []byte(`<orders xmlns="urn:orders">
+	<order id="1" type="express"/>
+	<order id="2"/>
+</orders>`)
+	xmlHandler, err := NewXmlHandlerMem(inXml, ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xmlHandler.Free()
+
+	err = sctHandler.Validate(xmlHandler, ValidErrDefault)
+	ve, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(ve.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %v", ve.Errors)
+	}
+
+	assert := ve.Errors[0]
+	if assert.Message != "Express order 1 needs a deliveryDate." || assert.Line != 2 ||
+		assert.Rule != "o:order[@type='express']" || assert.Assertion != "express-date" ||
+		assert.Path != "/orders/order[1]" || assert.Category() != "schematron" {
+		t.Errorf("unexpected assert error %#v", assert)
+	}
+	report := ve.Errors[1]
+	if report.Message != "order without type" || report.Line != 3 || report.Rule != "o:order" ||
+		report.Assertion != "no-type" || report.Path != "/orders/order[2]" {
+		t.Errorf("unexpected report error %#v", report)
+	}
+}
+
+func TestNewSchematronHandlerFail(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	sctHandler, err := NewSchematronHandlerMem([]byte(`<sch:schema xmlns:sch="http://purl.oclc.org/dsdl/schematron">
+	<sch:pattern>
+		<sch:rule context="order"><sch:assert test="1 +">broken</sch:assert></sch:rule>
+	</sch:pattern>
+</sch:schema>`), ParsErrDefault)
+	defer sctHandler.Free()
+	if _, ok := err.(SchematronParserError); !ok {
+		t.Fatalf("expected SchematronParserError, got %v", err)
+	}
+	errs := structErrors(err)
+	if len(errs) != 1 || errs[0].Line != 3 {
+		t.Errorf("unexpected errors %v", errs)
+	}
+
+	sctHandler, err = NewSchematronHandlerUrl("examples/test1_pass.xsd", ParsErrDefault)
+	defer sctHandler.Free()
+	if _, ok := err.(SchematronParserError); !ok {
+		t.Fatalf("expected SchematronParserError, got %v", err)
+	}
+
+	tests := []struct {
+		body    string
+		message string
+	}{
+		{`<sch:phase id="p"><sch:active pattern="a"/></sch:phase>`, "Unsupported element 'phase'"},
+		{`<sch:include href="rules.sch"/>`, "Unsupported element 'include'"},
+		{`<sch:diagnostics><sch:diagnostic id="d">hint</sch:diagnostic></sch:diagnostics>`, "Unsupported element 'diagnostics'"},
+		{`<sch:pattern abstract="true" id="a"/>`, "Unsupported abstract 'pattern'"},
+		{`<sch:pattern><sch:rule context="order"><sch:extends rule="missing"/></sch:rule></sch:pattern>`, "Unknown abstract rule 'missing'"},
+		{`<sch:pattern><sch:rule abstract="true" id="a"><sch:extends rule="a"/></sch:rule></sch:pattern>`, "Recursive abstract rule 'a'"},
+		{`<sch:let name="n" value="1 +"/>`, "Invalid expression '1 +'"},
+	}
+	for _, tc := range tests {
+		sctHandler, err := NewSchematronHandlerMem([]byte(`<sch:schema xmlns:sch="http://purl.oclc.org/dsdl/schematron">
+	`+tc.body+`
+</sch:schema>`), ParsErrDefault)
+		sctHandler.Free()
+		if _, ok := err.(SchematronParserError); !ok {
+			t.Fatalf("expected SchematronParserError for %s, got %v", tc.body, err)
+		}
+		if errs := structErrors(err); len(errs) != 1 || errs[0].Line != 2 || errs[0].Message != tc.message {
+			t.Errorf("expected %q in line 2, got %#v", tc.message, errs)
+		}
+	}
+}
+
+func TestSchematronLetAbstract(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	sctHandler, err := NewSchematronHandlerMem([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<sch:schema xmlns:sch="http://purl.oclc.org/dsdl/schematron">
+	<sch:let name="max" value="/orders/@max"/>
+	<sch:pattern>
+		<sch:let name="count" value="count(//order)"/>
+		<sch:rule abstract="true" id="has-id">
+			<sch:let name="id" value="@id"/>
+			<sch:assert id="id" test="string-length($id) > 0"><sch:name/> needs an id</sch:assert>
+		</sch:rule>
+		<sch:rule context="order">
+			<sch:extends rule="has-id"/>
+			<sch:let name="items" value="count(item)"/>
+			<sch:assert id="items" test="$items &lt;= $max">Order <sch:value-of select="$id"/> has <sch:value-of select="$items"/> of <sch:value-of select="$max"/> items</sch:assert>
+			<sch:report id="count" test="$count > 2">More than 2 orders</sch:report>
+		</sch:rule>
+	</sch:pattern>
+</sch:schema>`), ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sctHandler.Free()
+
+	if err := sctHandler.ValidateMem([]byte(`<orders max="2"><order id="1"><item/><item/></order></orders>`), ParsErrDefault); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	err = sctHandler.ValidateMem([]byte(`<orders max="1">
+	<order id="1"><item/><item/></order>
+	<order/>
+</orders>`), ParsErrDefault)
+	ve, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	want := []struct {
+		assertion string
+		message   string
+		line      int
+		path      string
+	}{
+		{"items", "Order 1 has 2 of 1 items", 2, "/orders/order[1]"},
+		{"id", "order needs an id", 3, "/orders/order[2]"},
+	}
+	if len(ve.Errors) != len(want) {
+		t.Fatalf("expected %d errors, got %v", len(want), ve.Errors)
+	}
+	for i, w := range want {
+		se := ve.Errors[i]
+		if se.Assertion != w.assertion || se.Message != w.message || se.Line != w.line || se.Path != w.path || se.Rule != "order" {
+			t.Errorf("unexpected error %#v, want %+v", se, w)
+		}
+	}
+}
+
+func TestSchematronLetShadowing(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	sctHandler, err := NewSchematronHandlerMem([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<sch:schema xmlns:sch="http://purl.oclc.org/dsdl/schematron">
+	<sch:let name="scope" value="'schema'"/>
+	<sch:pattern>
+		<sch:let name="scope" value="'pattern'"/>
+		<sch:rule context="a">
+			<sch:let name="scope" value="'rule'"/>
+			<sch:let name="first" value="1"/>
+			<sch:let name="second" value="2"/>
+			<sch:assert test="$scope = 'rule' and $first + $second = 3"><sch:name/> sees <sch:value-of select="$scope"/></sch:assert>
+		</sch:rule>
+		<sch:rule context="b">
+			<sch:assert test="$scope = 'pattern'"><sch:name/> sees <sch:value-of select="$scope"/></sch:assert>
+		</sch:rule>
+	</sch:pattern>
+	<sch:pattern>
+		<sch:rule context="a|b">
+			<sch:assert test="$scope = 'schema'"><sch:name/> sees <sch:value-of select="$scope"/></sch:assert>
+		</sch:rule>
+	</sch:pattern>
+</sch:schema>`), ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sctHandler.Free()
+
+	if err := sctHandler.ValidateMem([]byte(`<r><a/><b/><a/><b/></r>`), ParsErrDefault); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
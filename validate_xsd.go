@@ -5,6 +5,7 @@ package xsdvalidate
 
 import "C"
 import (
+	"io"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -37,16 +38,35 @@ var g guard
 type Options uint8
 
 // The parser options, ParsErrVerbose will slow down parsing considerably!
+// XIncludes of a document parsed with ParsXInclude may only name files in the directory its hrefs are resolved against
+// or below it, other hrefs are reported as XmlParserError and network access is disabled. Any file in that directory can
+// be included though, parse="text" includes even files that are not xml, and the included content ends up in the document
+// and its error messages. Parse untrusted documents with ParsXInclude only in a directory holding nothing else than
+// the files to include, e.g. with NewXmlHandlerMemBase, the XIncludes of included files are not restricted.
 const (
 	ParsErrDefault Options = 1 << iota // Default parser error output
 	ParsErrVerbose                     // Verbose parser error output, considerably slower!
+	ParsXInclude                       // Process XIncludes, relative hrefs are resolved against the document URL, for NewXmlHandlerMem and ValidateMem against the working directory
 )
 
-// Validation options for possible future enhancements.
+
+// Validation options.
 const (
-	ValidErrDefault Options = 128 << iota // Default validation error output
+	ValidErrDefault  Options = 128 // Default validation error output
+	ValidErrRedact   Options = 8   // Mask document values in error messages, StructError.Value keeps the value
+	ValidAddDefaults Options = 16  // Add schema default and fixed values to the validated document, only useful with Validate
+	ValidLaxForeign  Options = 32  // Skip elements and attributes of namespaces the schema does not cover unless a wildcard allows them
+	ValidWarnForeign Options = 64  // With ValidLaxForeign report skipped elements and attributes as LevelWarning StructError
 )
 
+// SaveOptions for serializing the document of an XmlHandler.
+type SaveOptions struct {
+	Encoding      string // Output encoding like "UTF-8" or "ISO-8859-1", if empty the encoding of the document or UTF-8 if it declares none
+	Format        bool   // Pretty-print the document
+	NoDeclaration bool   // Omit the xml declaration
+	ExpandEmpty   bool   // Write empty elements as start and end tag instead of self-closing tags
+}
+
 var quit chan struct{}
 
 // Init initializes libxml2, see http://xmlsoft.org/threads.html.
@@ -54,7 +74,7 @@ func Init() error {
 	g.Lock()
 	defer g.Unlock()
 	if g.isInitialized() {
-		return Libxml2Error{errorMessage{"Libxml2 already initialized"}}
+		return Libxml2Error{errorMessage{"Libxml2 already initialized", ErrAlreadyInitialized}}
 	}
 
 	libXml2Init()
@@ -88,11 +108,34 @@ func Cleanup() {
 // The go garbage collector will not collect the allocated resources.
 func NewXmlHandlerMem(inXml []byte, options Options) (*XmlHandler, error) {
 	if !g.isInitialized() {
-		return nil, Libxml2Error{errorMessage{"Libxml2 not initialized"}}
+		return nil, Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+
+	return parseXmlMem(inXml, "", options)
+}
+
+// NewXmlHandlerMemBase creates a xml handler struct like NewXmlHandlerMem, baseUrl is the document URL used for resolving XIncludes.
+// If an error is returned it can be of type Libxml2Error or XmlParserError.
+// Always use the Free() method when done using this handler or memory will be leaking.
+// The go garbage collector will not collect the allocated resources.
+func NewXmlHandlerMemBase(inXml []byte, baseUrl string, options Options) (*XmlHandler, error) {
+	if !g.isInitialized() {
+		return nil, Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+
+	return parseXmlMem(inXml, baseUrl, options)
+}
+
+// NewXmlHandlerUrl creates a xml handler struct from a file or URL, which is the base URI for resolving XIncludes.
+// If an error is returned it can be of type Libxml2Error or XmlParserError.
+// Always use the Free() method when done using this handler or memory will be leaking.
+// The go garbage collector will not collect the allocated resources.
+func NewXmlHandlerUrl(url string, options Options) (*XmlHandler, error) {
+	if !g.isInitialized() {
+		return nil, Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
 	}
 
-	xPtr, err := parseXmlMem(inXml, options)
-	return &XmlHandler{xPtr}, err
+	return parseXmlUrl(url, options)
 }
 
 // NewXsdHandlerUrl creates a xsd handler struct.
@@ -103,10 +146,9 @@ func NewXsdHandlerUrl(url string, options Options) (*XsdHandler, error) {
 	g.Lock()
 	defer g.Unlock()
 	if !g.isInitialized() {
-		return nil, Libxml2Error{errorMessage{"Libxml2 not initialized"}}
+		return nil, Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
 	}
-	sPtr, err := parseUrlSchema(url, options)
-	return &XsdHandler{sPtr}, err
+	return parseUrlSchema(url, options)
 }
 
 // NewXsdHandlerMem creates an xsd handler struct.
@@ -117,51 +159,105 @@ func NewXsdHandlerMem(inSchema []byte, options Options) (*XsdHandler, error) {
 	g.Lock()
 	defer g.Unlock()
 	if !g.isInitialized() {
-		return nil, Libxml2Error{errorMessage{"Libxml2 not initialized"}}
+		return nil, Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
 	}
-	sPtr, err := parseMemSchema(inSchema, options)
-	return &XsdHandler{sPtr}, err
+	return parseMemSchema(inSchema, options)
 }
 
 // Validate validates an xmlHandler against an xsdHandler and returns a ValidationError.
-// If an error is returned it is of type Libxml2Error, XsdParserError, XmlParserError or ValidationError.
+// With ValidErrRedact document values are masked in the error messages.
+// With ValidAddDefaults the document of the xmlHandler is augmented with defaulted attributes and element values, use Bytes to serialize it.
+// With ValidLaxForeign foreign elements and attributes the schema rejects are removed from a copy of the document before it is validated,
+// content matched by wildcards like xs:any namespace="##other" is kept. The document of the xmlHandler is not modified,
+// error lines and paths refer to it. With ValidWarnForeign the skipped nodes are reported as warnings,
+// a ValidationError with only LevelWarning errors is returned for an otherwise valid document. Foreign content libxml2 only reports
+// after earlier foreign content is removed takes another validation pass, after 16 passes the remaining foreign content is
+// validated and reported as usual, preceded by a LevelWarning StructError.
+// If an error is returned it is of type Libxml2Error, XsdParserError, XmlParserError, RootElementError or ValidationError.
 // Both xmlHandler and xsdHandler have to be created first.
 func (xsdHandler *XsdHandler) Validate(xmlHandler *XmlHandler, options Options) error {
 	if !g.isInitialized() {
-		return Libxml2Error{errorMessage{"Libxml2 not initialized"}}
+		return Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
 	}
 
 	if xsdHandler == nil || xsdHandler.schemaPtr == nil {
-		return XsdParserError{errorMessage{"Xsd handler not properly initialized"}}
+		return XsdParserError{errorMessage{"Xsd handler not properly initialized", ErrHandlerNotInitialized}}
 
 	}
 	if xmlHandler == nil || xmlHandler.docPtr == nil {
-		return XmlParserError{errorMessage{"Xml handler not properly initialized"}}
+		return XmlParserError{errorMessage: errorMessage{"Xml handler not properly initialized", ErrHandlerNotInitialized}}
+	}
+	return validateWithXsd(xmlHandler, xsdHandler, options)
+
+}
+
+// ValidateElement validates the first element selected by xpath against the matching global element declaration of the xsdHandler's schema.
+// Namespace prefixes declared on the root element of the document can be used in the expression, line numbers refer to the whole document.
+// If an error is returned it is of type Libxml2Error, XsdParserError, XmlParserError, XPathError or ValidationError.
+func (xsdHandler *XsdHandler) ValidateElement(xmlHandler *XmlHandler, xpath string, options Options) error {
+	if !g.isInitialized() {
+		return Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
 	}
-	return validateWithXsd(xmlHandler, xsdHandler)
 
+	if xsdHandler == nil || xsdHandler.schemaPtr == nil {
+		return XsdParserError{errorMessage{"Xsd handler not properly initialized", ErrHandlerNotInitialized}}
+
+	}
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return XmlParserError{errorMessage: errorMessage{"Xml handler not properly initialized", ErrHandlerNotInitialized}}
+	}
+	return validateXPathWithXsd(xmlHandler, xpath, xsdHandler, options)
 }
 
 // ValidateMem validates an xml byte slice against an xsdHandler.
-// If an error is returned it can be of type Libxml2Error, XsdParserError, XmlParserError or ValidationError.
+// If an error is returned it can be of type Libxml2Error, XsdParserError, XmlParserError, RootElementError or ValidationError.
 // The xsdHandler has to be created first.
 func (xsdHandler *XsdHandler) ValidateMem(inXml []byte, options Options) error {
 	if !g.isInitialized() {
-		return Libxml2Error{errorMessage{"Libxml2 not initialized"}}
+		return Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
 	}
 	if xsdHandler == nil || xsdHandler.schemaPtr == nil {
-		return XsdParserError{errorMessage{"Xsd handler not properly initialized"}}
+		return XsdParserError{errorMessage{"Xsd handler not properly initialized", ErrHandlerNotInitialized}}
 
 	}
 	return validateBufWithXsd(inXml, options, xsdHandler)
 
 }
 
+// SetRootElements restricts the root elements accepted by Validate and ValidateMem, a document with another root element fails with a RootElementError.
+// Without root elements every global element declaration of the schema is accepted. Call this before the handler is used concurrently.
+func (xsdHandler *XsdHandler) SetRootElements(roots ...QName) {
+	xsdHandler.roots = append([]QName(nil), roots...)
+}
+
 // Free frees the wrapped schemaPtr, call this when this handler is not needed anymore.
 func (xsdHandler *XsdHandler) Free() {
 	freeSchemaPtr(xsdHandler)
 }
 
+// Bytes serializes the document of the xmlHandler, including values added by validating with ValidAddDefaults.
+// If an error is returned it is of type Libxml2Error or XmlParserError.
+func (xmlHandler *XmlHandler) Bytes(options SaveOptions) ([]byte, error) {
+	if !g.isInitialized() {
+		return nil, Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return nil, XmlParserError{errorMessage: errorMessage{"Xml handler not properly initialized", ErrHandlerNotInitialized}}
+	}
+	return dumpDoc(xmlHandler, options)
+}
+
+// WriteTo writes the document of the xmlHandler serialized with the default SaveOptions to w, it implements the io.WriterTo interface.
+// If an error is returned it is of type Libxml2Error, XmlParserError or the error of w.
+func (xmlHandler *XmlHandler) WriteTo(w io.Writer) (int64, error) {
+	out, err := xmlHandler.Bytes(SaveOptions{})
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(out)
+	return int64(n), err
+}
+
 // Free frees the wrapped xml docPtr, call this when this handler is not needed anymore.
 func (xmlHandler *XmlHandler) Free() {
 	freeDocPtr(xmlHandler)
@@ -4,9 +4,11 @@
 package xsdvalidate
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -253,3 +255,541 @@ func TestIsInitialized(t *testing.T) {
 	}
 	Cleanup()
 }
+
+func TestValidationErrorExpected(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsdhandler, err := NewXsdHandlerUrl("examples/test1_split.xsd", ParsErrDefault)
+	if err != nil {
+		fmt.Printf("Error: %s %s\n", t.Name(), err.Error())
+		t.Fail()
+	}
+	defer xsdhandler.Free()
+
+	inXml, err := ioutil.ReadFile("examples/test1_fail2.xml")
+	if err != nil {
+		fmt.Printf("Error: %s %s\n", t.Name(), err.Error())
+		return
+	}
+
+	err = xsdhandler.ValidateMem(inXml, ParsErrDefault)
+	ve, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	se := ve.Errors[0]
+	if se.Found != (QName{Local: "shipto"}) {
+		t.Errorf("unexpected Found %v", se.Found)
+	}
+	if len(se.Expected) != 1 || se.Expected[0] != (QName{Local: "orderperson"}) {
+		t.Errorf("unexpected Expected %v", se.Expected)
+	}
+}
+
+func TestParseExpected(t *testing.T) {
+	expected := parseExpected("Element '{urn:a}x': This element is not expected. Expected is one of ( {urn:a}b, c, ... ).")
+	if len(expected) != 2 || expected[0] != (QName{"urn:a", "b"}) || expected[1] != (QName{"", "c"}) {
+		t.Errorf("unexpected Expected %v", expected)
+	}
+	if found := parseFound("Element '{urn:a}x': This element is not expected. Expected is ( b )."); found != (QName{"urn:a", "x"}) {
+		t.Errorf("unexpected Found %v", found)
+	}
+	if found := parseFound("Element 'x': Missing child element(s). Expected is ( b )."); found != (QName{}) {
+		t.Errorf("unexpected Found %v", found)
+	}
+}
+
+func TestValidationErrorSuggestions(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsd := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+	<xs:element name="order">
+		<xs:complexType>
+			<xs:sequence>
+				<xs:element name="color">
+					<xs:simpleType>
+						<xs:restriction base="xs:string">
+							<xs:enumeration value="red"/>
+							<xs:enumeration value="green"/>
+							<xs:enumeration value="blue"/>
+							<xs:enumeration value="don't, {care}."/>
+							<xs:enumeration value="red', 'white"/>
+						</xs:restriction>
+					</xs:simpleType>
+				</xs:element>
+				<xs:element name="quantity" type="xs:int"/>
+			</xs:sequence>
+		</xs:complexType>
+	</xs:element>
+</xs:schema>`)
+
+	xsdhandler, err := NewXsdHandlerMem(xsd, ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	tests := []struct {
+		xml  string
+		want string
+	}{
+		{`<order><color>gren</color><quantity>1</quantity></order>`, "green"},
+		{`<order><color>dont, {care}.</color><quantity>1</quantity></order>`, "don't, {care}."},
+		{`<order><color>red', 'whit</color><quantity>1</quantity></order>`, "red', 'white"},
+		{`<order><color>red</color><quantiy>1</quantiy></order>`, "quantity"},
+		{`<oder><color>red</color><quantity>1</quantity></oder>`, "order"},
+	}
+	for _, tc := range tests {
+		err = xsdhandler.ValidateMem([]byte(tc.xml), ParsErrDefault)
+		ve, ok := err.(ValidationError)
+		if !ok {
+			t.Fatalf("expected ValidationError, got %v", err)
+		}
+		if s := ve.Errors[0].Suggestions; len(s) == 0 || s[0] != tc.want {
+			t.Errorf("%s: expected suggestion %q, got %v", ve.Errors[0].Message, tc.want, s)
+		}
+	}
+}
+
+func TestErrorsIsAs(t *testing.T) {
+	if _, err := NewXmlHandlerMem([]byte("<a/>"), ParsErrDefault); !errors.Is(err, ErrNotInitialized) {
+		t.Errorf("expected ErrNotInitialized, got %v", err)
+	}
+
+	Init()
+	defer Cleanup()
+
+	if err := Init(); !errors.Is(err, ErrAlreadyInitialized) {
+		t.Errorf("expected ErrAlreadyInitialized, got %v", err)
+	}
+
+	var xsdhandler *XsdHandler
+	if err := xsdhandler.ValidateMem([]byte("<a/>"), ParsErrDefault); !errors.Is(err, ErrHandlerNotInitialized) {
+		t.Errorf("expected ErrHandlerNotInitialized, got %v", err)
+	}
+
+	xsdhandler, err := NewXsdHandlerUrl("examples/test1_split.xsd", ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	if err := xsdhandler.Validate(nil, ValidErrDefault); !errors.Is(err, ErrHandlerNotInitialized) {
+		t.Errorf("expected ErrHandlerNotInitialized, got %v", err)
+	}
+
+	inXml, err := ioutil.ReadFile("examples/test1_fail2.xml")
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	err = xsdhandler.ValidateMem(inXml, ParsErrDefault)
+	var se StructError
+	if !errors.As(err, &se) || se.Line != 3 {
+		t.Errorf("expected StructError in line 3, got %v", err)
+	}
+	// As is called directly, errors.As only follows Unwrap() []error from go 1.20 on.
+	var ase StructError
+	if !err.(ValidationError).As(&ase) || ase.Line != 3 {
+		t.Errorf("expected ValidationError.As to find a StructError in line 3, got %v", ase)
+	}
+}
+
+func TestValidateAddDefaults(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsd := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+	<xs:element name="order">
+		<xs:complexType>
+			<xs:sequence>
+				<xs:element name="currency" type="xs:string" default="EUR"/>
+			</xs:sequence>
+			<xs:attribute name="priority" type="xs:string" default="normal"/>
+			<xs:attribute name="version" type="xs:string" fixed="1.0"/>
+		</xs:complexType>
+	</xs:element>
+</xs:schema>`)
+
+	xsdhandler, err := NewXsdHandlerMem(xsd, ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	for _, options := range []Options{ValidErrDefault, ValidAddDefaults} {
+		xmlhandler, err := NewXmlHandlerMem([]byte(`<order><currency/></order>`), ParsErrDefault)
+		if err != nil {
+			t.Fatalf("%s %s", t.Name(), err.Error())
+		}
+		if err := xsdhandler.Validate(xmlhandler, options); err != nil {
+			t.Fatalf("%s %s", t.Name(), err.Error())
+		}
+		out, err := xmlhandler.Bytes(SaveOptions{})
+		xmlhandler.Free()
+		if err != nil {
+			t.Fatalf("%s %s", t.Name(), err.Error())
+		}
+		added := strings.Contains(string(out), `priority="normal"`) && strings.Contains(string(out), `version="1.0"`) &&
+			strings.Contains(string(out), `<currency>EUR</currency>`)
+		if added != (options == ValidAddDefaults) {
+			t.Errorf("unexpected document with options %d: %s", options, out)
+		}
+	}
+}
+
+func TestValidateElement(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsdhandler, err := NewXsdHandlerUrl("examples/test1_split.xsd", ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	inXml := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope">
+	<env:Body>
+		<shiporder orderid="889923">
+			<shipto>
+				<name>Ola Nordmann</name>
+				<address>Langgt 23</address>
+				<city>4000 Stavanger</city>
+				<country>Norway</country>
+			</shipto>
+		</shiporder>
+	</env:Body>
+</env:Envelope>`)
+
+	xmlhandler, err := NewXmlHandlerMem(inXml, ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xmlhandler.Free()
+
+	err = xsdhandler.ValidateElement(xmlhandler, "/env:Envelope/env:Body/shiporder", ValidErrDefault)
+	ve, ok := err.(ValidationError)
+	if !ok || ve.Errors[0].Line != 5 || ve.Errors[0].Found != (QName{Local: "shipto"}) {
+		t.Errorf("expected ValidationError in line 5, got %v", err)
+	}
+
+	if err := xsdhandler.ValidateElement(xmlhandler, "//nothing", ValidErrDefault); err == nil {
+		t.Fail()
+	} else if _, ok := err.(XPathError); !ok {
+		t.Errorf("expected XPathError, got %v", err)
+	}
+
+	if err := xsdhandler.ValidateElement(xmlhandler, "/env:Envelope/env:Body[", ValidErrDefault); err == nil {
+		t.Fail()
+	} else if xe, ok := err.(XPathError); !ok || !strings.HasPrefix(xe.Message, "Invalid xpath expression") {
+		t.Errorf("expected XPathError for the invalid expression, got %v", err)
+	}
+}
+
+func TestValidateElementPass(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsdhandler, err := NewXsdHandlerUrl("examples/test1_split.xsd", ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	inXml := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope">
+	<env:Body>
+		<shiporder orderid="889923">
+			<orderperson>John Smith</orderperson>
+			<shipto>
+				<name>Ola Nordmann</name>
+				<address>Langgt 23</address>
+				<city>4000 Stavanger</city>
+				<country>Norway</country>
+			</shipto>
+			<item>
+				<title>Hide your heart</title>
+				<quantity>1</quantity>
+				<price>9.90</price>
+			</item>
+		</shiporder>
+		<shiporder orderid="889924">
+			<orderperson>John Smith</orderperson>
+			<item>
+				<title>Hide your heart</title>
+				<quantity>one</quantity>
+				<price>9.90</price>
+			</item>
+		</shiporder>
+	</env:Body>
+</env:Envelope>`)
+
+	xmlhandler, err := NewXmlHandlerMem(inXml, ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xmlhandler.Free()
+
+	if err := xsdhandler.Validate(xmlhandler, ValidErrDefault); err == nil {
+		t.Errorf("expected the envelope to fail whole document validation")
+	}
+	if err := xsdhandler.ValidateElement(xmlhandler, "//shiporder[@orderid='889923']", ValidErrDefault); err != nil {
+		t.Errorf("expected the first payload to be valid, got %v", err)
+	}
+
+	err = xsdhandler.ValidateElement(xmlhandler, "//shiporder[2]", ValidErrDefault)
+	ve, ok := err.(ValidationError)
+	if !ok || ve.Errors[0].Line != 20 {
+		t.Errorf("expected ValidationError in line 20 of the document, got %v", err)
+	}
+}
+
+func TestSetRootElements(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsd := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+	<xs:element name="shiporder" type="xs:string"/>
+	<xs:element name="item" type="xs:string"/>
+</xs:schema>`)
+
+	xsdhandler, err := NewXsdHandlerMem(xsd, ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	if err := xsdhandler.ValidateMem([]byte("<item/>"), ParsErrDefault); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	xsdhandler.SetRootElements(QName{Local: "shiporder"})
+	if err := xsdhandler.ValidateMem([]byte("<shiporder/>"), ParsErrDefault); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	err = xsdhandler.ValidateMem([]byte("<item/>"), ParsErrDefault)
+	if re, ok := err.(RootElementError); !ok || re.Root != (QName{Local: "item"}) || !errors.Is(err, ErrRootNotAllowed) {
+		t.Errorf("expected RootElementError, got %v", err)
+	}
+
+	xmlhandler, err := NewXmlHandlerMem([]byte("<item/>"), ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xmlhandler.Free()
+	if err := xsdhandler.Validate(xmlhandler, ValidErrDefault); !errors.Is(err, ErrRootNotAllowed) {
+		t.Errorf("expected RootElementError, got %v", err)
+	}
+}
+
+func TestValidateLaxForeign(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsd := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:order" xmlns="urn:order" elementFormDefault="qualified">
+	<xs:element name="order">
+		<xs:complexType>
+			<xs:sequence>
+				<xs:element name="id" type="xs:int"/>
+				<xs:element name="note" type="xs:string"/>
+			</xs:sequence>
+		</xs:complexType>
+	</xs:element>
+</xs:schema>`)
+
+	xsdhandler, err := NewXsdHandlerMem(xsd, ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	inXml := []byte(`<order xmlns="urn:order" xmlns:p="urn:partner" p:ref="x">
+	<id p:checked="true">1</id>
+	<p:extension><p:flag/></p:extension>
+	<note>ok</note>
+</order>`)
+	if err := xsdhandler.ValidateMem(inXml, ParsErrDefault); err == nil {
+		t.Errorf("expected strict validation error")
+	}
+	if err := xsdhandler.ValidateMem(inXml, ParsErrDefault|ValidLaxForeign); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	invalid := []byte(`<order xmlns="urn:order" xmlns:p="urn:partner">
+	<p:extension/>
+	<id>x</id>
+	<note>ok</note>
+</order>`)
+	xmlhandler, err := NewXmlHandlerMem(invalid, ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xmlhandler.Free()
+	err = xsdhandler.Validate(xmlhandler, ValidLaxForeign)
+	if ve, ok := err.(ValidationError); !ok || len(ve.Errors) != 1 || ve.Errors[0].Line != 3 {
+		t.Errorf("expected ValidationError in line 3, got %v", err)
+	}
+	out, _ := xmlhandler.Bytes(SaveOptions{})
+	if !strings.Contains(string(out), "p:extension") {
+		t.Errorf("expected unmodified document, got %s", out)
+	}
+}
+
+func TestValidateLaxForeignWildcard(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsd := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:order" xmlns="urn:order" elementFormDefault="qualified">
+	<xs:element name="order">
+		<xs:complexType>
+			<xs:sequence>
+				<xs:element name="id" type="xs:int"/>
+				<xs:any namespace="##other" processContents="lax"/>
+				<xs:element name="note" type="xs:string"/>
+			</xs:sequence>
+			<xs:attribute name="status" type="xs:string" default="new"/>
+			<xs:anyAttribute namespace="urn:signature" processContents="lax"/>
+		</xs:complexType>
+	</xs:element>
+</xs:schema>`)
+
+	xsdhandler, err := NewXsdHandlerMem(xsd, ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	valid := []byte(`<order xmlns="urn:order" xmlns:s="urn:signature" s:digest="abc">
+	<id>1</id>
+	<s:signature/>
+	<note>ok</note>
+</order>`)
+	for _, options := range []Options{ParsErrDefault, ParsErrDefault | ValidLaxForeign} {
+		if err := xsdhandler.ValidateMem(valid, options); err != nil {
+			t.Errorf("expected wildcard content to be valid with options %d, got %v", options, err)
+		}
+	}
+
+	inXml := []byte(`<order xmlns="urn:order" xmlns:s="urn:signature" xmlns:p="urn:partner" p:ref="x">
+	<p:before/>
+	<id>one</id>
+	<s:signature/>
+	<p:after/>
+	<note>ok</note>
+</order>`)
+	xmlhandler, err := NewXmlHandlerMem(inXml, ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xmlhandler.Free()
+
+	err = xsdhandler.Validate(xmlhandler, ValidLaxForeign|ValidWarnForeign|ValidAddDefaults)
+	ve, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	want := []struct {
+		level int
+		line  int
+		path  string
+	}{
+		{LevelWarning, 1, "/*/@p:ref"},
+		{LevelWarning, 2, "/*/p:before"},
+		{LevelWarning, 5, "/*/p:after"},
+		{LevelError, 3, "/*/*[2]"},
+	}
+	if len(ve.Errors) != len(want) {
+		t.Fatalf("expected %d errors, got %v", len(want), ve.Errors)
+	}
+	for i, w := range want {
+		if e := ve.Errors[i]; e.Level != w.level || e.Line != w.line || e.Path != w.path {
+			t.Errorf("expected level %d in line %d at %s, got %+v", w.level, w.line, w.path, e)
+		}
+	}
+
+	out, _ := xmlhandler.Bytes(SaveOptions{})
+	if !strings.Contains(string(out), "p:before") || !strings.Contains(string(out), `status="new"`) {
+		t.Errorf("expected the foreign content to be kept and the default to be added, got %s", out)
+	}
+}
+
+func TestValidateLaxForeignPasses(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsd := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+	<xs:element name="order">
+		<xs:complexType>
+			<xs:sequence>
+				<xs:element name="note" type="xs:string"/>
+			</xs:sequence>
+		</xs:complexType>
+	</xs:element>
+</xs:schema>`)
+	xsdhandler, err := NewXsdHandlerMem(xsd, ParsErrDefault)
+	if err != nil {
+		t.Fatalf("%s %s", t.Name(), err.Error())
+	}
+	defer xsdhandler.Free()
+
+	// a run of foreign siblings of one namespace is skipped in a single pass
+	run := `<order xmlns:p="urn:p"><note>ok</note>` + strings.Repeat("<p:x/>", 100) + `</order>`
+	if err := xsdhandler.ValidateMem([]byte(run), ValidLaxForeign); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	// alternating namespaces need a pass per element, the passes are limited
+	alternating := `<order xmlns:p="urn:p" xmlns:q="urn:q"><note>ok</note>` + strings.Repeat("<p:x/><q:x/>", 20) + `</order>`
+	err = xsdhandler.ValidateMem([]byte(alternating), ValidLaxForeign)
+	ve, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if ve.Errors[0].Level != LevelWarning || !strings.Contains(ve.Errors[0].Message, "validation passes") {
+		t.Errorf("expected a warning about the validation passes, got %+v", ve.Errors[0])
+	}
+	if len(ve.Errors) < 2 || ve.Errors[1].Level != LevelError {
+		t.Errorf("expected the remaining foreign content to be reported, got %v", ve.Errors)
+	}
+}
+
+func TestXmlHandlerBytesOptions(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xmlhandler, err := NewXmlHandlerMem([]byte(`<?xml version="1.0" encoding="UTF-8"?><order><name>Müller</name><note/></order>`), ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xmlhandler.Free()
+
+	out, err := xmlhandler.Bytes(SaveOptions{})
+	if err != nil || string(out) != "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<order><name>Müller</name><note/></order>\n" {
+		t.Errorf("unexpected default output %q %v", out, err)
+	}
+	out, err = xmlhandler.Bytes(SaveOptions{Format: true, NoDeclaration: true, ExpandEmpty: true})
+	if err != nil || string(out) != "<order>\n  <name>Müller</name>\n  <note></note>\n</order>\n" {
+		t.Errorf("unexpected formatted output %q %v", out, err)
+	}
+	out, err = xmlhandler.Bytes(SaveOptions{Encoding: "ISO-8859-1"})
+	if err != nil || !strings.Contains(string(out), "encoding=\"ISO-8859-1\"") || !strings.Contains(string(out), "M\xfcller") {
+		t.Errorf("unexpected latin1 output %q %v", out, err)
+	}
+	if _, err := xmlhandler.Bytes(SaveOptions{Encoding: "no-such-encoding"}); err == nil {
+		t.Error("expected error for an unsupported encoding")
+	}
+
+	var sb strings.Builder
+	n, err := xmlhandler.WriteTo(&sb)
+	if err != nil || n != int64(sb.Len()) || !strings.HasPrefix(sb.String(), "<?xml") {
+		t.Errorf("unexpected WriteTo output %q %d %v", sb.String(), n, err)
+	}
+}
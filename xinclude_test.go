@@ -0,0 +1,169 @@
+//go:build apitest
+// +build apitest
+
+package xsdvalidate
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestValidateXInclude(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsdHandler, err := NewXsdHandlerUrl("examples/test1_pass.xsd", ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xsdHandler.Free()
+
+	xmlHandler, err := NewXmlHandlerUrl("examples/test1_xinclude.xml", ParsErrDefault|ParsXInclude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xmlHandler.Free()
+
+	err = xsdHandler.Validate(xmlHandler, ValidErrDefault)
+	ve, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(ve.Errors) != 1 || ve.Errors[0].File != "examples/test1_xinclude_item.xml" || ve.Errors[0].Line != 6 {
+		t.Errorf("expected error in the included file, got %#v", ve.Errors)
+	}
+
+	noInclude, err := NewXmlHandlerUrl("examples/test1_xinclude.xml", ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer noInclude.Free()
+	err = xsdHandler.Validate(noInclude, ValidErrDefault)
+	if ve, ok := err.(ValidationError); !ok || ve.Errors[0].File != "examples/test1_xinclude.xml" || ve.Errors[0].Line != 10 {
+		t.Errorf("expected error for the xi:include element, got %v", err)
+	}
+}
+
+func TestXIncludeParserError(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	inXml := []byte(`<shiporder xmlns:xi="http://www.w3.org/2001/XInclude"><xi:include href="examples/test1_fail1_1.xml"/></shiporder>`)
+	_, err := NewXmlHandlerMem(inXml, ParsErrVerbose|ParsXInclude)
+	if _, ok := err.(XmlParserError); !ok {
+		t.Fatalf("expected XmlParserError, got %v", err)
+	}
+	found := false
+	for _, se := range structErrors(err) {
+		if se.File == "examples/test1_fail1_1.xml" && se.Line == 9 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected parser error in the included file, got %v", structErrors(err))
+	}
+
+	pass, err := ioutil.ReadFile("examples/test1_pass.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	xsdHandler, err := NewXsdHandlerUrl("examples/test1_pass.xsd", ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xsdHandler.Free()
+	if err := xsdHandler.ValidateMem(pass, ParsErrDefault|ParsXInclude); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err := xsdHandler.ValidateMem(inXml, ParsErrDefault|ParsXInclude); err == nil {
+		t.Error("expected error for a failing XInclude")
+	}
+}
+
+func TestXIncludeParserErrorDefault(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xsdHandler, err := NewXsdHandlerUrl("examples/test1_pass.xsd", ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xsdHandler.Free()
+
+	tests := []struct {
+		href  string
+		file  string
+		line  int
+		level int
+	}{
+		{"examples/test1_fail1_1.xml", "examples/test1_fail1_1.xml", 9, LevelFatal},
+		// the xi:include element is reported as the document parsed from memory has no URL
+		{"examples/missing.xml", "", 1, LevelFatal},
+	}
+	for _, tc := range tests {
+		inXml := []byte(`<shiporder xmlns:xi="http://www.w3.org/2001/XInclude"><xi:include href="` + tc.href + `"/></shiporder>`)
+		_, handlerErr := NewXmlHandlerMem(inXml, ParsErrDefault|ParsXInclude)
+		for _, err := range []error{handlerErr, xsdHandler.ValidateMem(inXml, ParsErrDefault|ParsXInclude)} {
+			if _, ok := err.(XmlParserError); !ok {
+				t.Fatalf("expected XmlParserError, got %v", err)
+			}
+			found := false
+			for _, se := range structErrors(err) {
+				if se.File == tc.file && se.Line == tc.line && se.Level == tc.level {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("%s: expected error in %q line %d, got %v", tc.href, tc.file, tc.line, structErrors(err))
+			}
+		}
+	}
+}
+
+func TestXIncludeBaseUrl(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	inXml, err := ioutil.ReadFile("examples/test1_xinclude.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewXmlHandlerMem(inXml, ParsErrDefault|ParsXInclude); err == nil {
+		t.Error("expected error for hrefs resolved against the working directory")
+	}
+	xmlHandler, err := NewXmlHandlerMemBase(inXml, "examples/test1_xinclude.xml", ParsErrDefault|ParsXInclude)
+	if err != nil {
+		t.Fatalf("expected hrefs resolved against the base URL, got %v", err)
+	}
+	defer xmlHandler.Free()
+}
+
+func TestXIncludeOutsideDirectory(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	for _, include := range []string{
+		`<xi:include href="/etc/hostname" parse="text"/>`,
+		`<xi:include href="../go.mod" parse="text"/>`,
+		`<xi:include href="examples/%2e%2e/go.mod" parse="text"/>`,
+		`<xi:include href="http://localhost/order.xml"/>`,
+		`<note xml:base="/etc/"><xi:include href="hostname" parse="text"/></note>`,
+	} {
+		inXml := []byte(`<shiporder xmlns:xi="http://www.w3.org/2001/XInclude">` + include + `</shiporder>`)
+		_, err := NewXmlHandlerMem(inXml, ParsErrDefault|ParsXInclude)
+		errs := structErrors(err)
+		if _, ok := err.(XmlParserError); !ok || len(errs) != 1 || errs[0].Line != 1 || errs[0].Level != LevelFatal ||
+			!strings.Contains(errs[0].Message, "outside of the directory") {
+			t.Errorf("%s: expected XmlParserError, got %v", include, err)
+		}
+	}
+
+	// hrefs of a document loaded from a file resolve against its directory
+	xmlHandler, err := NewXmlHandlerMemBase([]byte(`<shiporder xmlns:xi="http://www.w3.org/2001/XInclude"><xi:include href="../go.mod" parse="text"/></shiporder>`),
+		"examples/order.xml", ParsErrDefault|ParsXInclude)
+	if _, ok := err.(XmlParserError); !ok {
+		t.Errorf("expected XmlParserError for a parent directory, got %v", err)
+	}
+	xmlHandler.Free()
+}
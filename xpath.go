@@ -0,0 +1,68 @@
+package xsdvalidate
+
+// XPathType is the type of an xpath result.
+type XPathType int
+
+// The xpath result types.
+const (
+	XPathNodeSet XPathType = iota + 1
+	XPathBoolean
+	XPathNumber
+	XPathString
+)
+
+// XPathResult is the result of an xpath expression.
+// The accessors convert the result following the XPath 1.0 rules, e.g. String returns the value of the first node of a node-set
+// and Number of a string that is not a number is NaN.
+type XPathResult struct {
+	Type    XPathType
+	nodes   []Node
+	str     string
+	num     float64
+	boolean bool
+}
+
+// Nodes returns the nodes of a node-set result in document order, nil for other result types.
+func (r XPathResult) Nodes() []Node {
+	return r.nodes
+}
+
+// String returns the result converted to a string.
+func (r XPathResult) String() string {
+	return r.str
+}
+
+// Number returns the result converted to a number.
+func (r XPathResult) Number() float64 {
+	return r.num
+}
+
+// Bool returns the result converted to a boolean, for node-sets whether it is not empty.
+func (r XPathResult) Bool() bool {
+	return r.boolean
+}
+
+// XPath evaluates an xpath 1.0 expression on the document.
+// Namespaces maps the prefixes used in the expression to namespace uris, prefixes declared on the root element can be used without mapping them.
+// Returned nodes are only valid as long as the xmlHandler is not freed.
+// If an error is returned it is of type Libxml2Error, XmlParserError or XPathError.
+func (xmlHandler *XmlHandler) XPath(expr string, namespaces map[string]string) (XPathResult, error) {
+	if !g.isInitialized() {
+		return XPathResult{}, Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+	if xmlHandler == nil || xmlHandler.docPtr == nil {
+		return XPathResult{}, XmlParserError{errorMessage: errorMessage{"Xml handler not properly initialized", ErrHandlerNotInitialized}}
+	}
+	return evalXPathResult(xmlHandler.docPtr, nil, expr, namespaces)
+}
+
+// XPath evaluates an xpath 1.0 expression with the node as context node, see XmlHandler.XPath.
+func (node Node) XPath(expr string, namespaces map[string]string) (XPathResult, error) {
+	if !g.isInitialized() {
+		return XPathResult{}, Libxml2Error{errorMessage{"Libxml2 not initialized", ErrNotInitialized}}
+	}
+	if node.nodePtr == nil {
+		return XPathResult{}, XPathError{errorMessage{"Node not properly initialized", ErrHandlerNotInitialized}}
+	}
+	return evalXPathResult(node.nodePtr.doc, node.nodePtr, expr, namespaces)
+}
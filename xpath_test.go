@@ -0,0 +1,84 @@
+//go:build apitest
+// +build apitest
+
+package xsdvalidate
+
+import (
+	"io/ioutil"
+	"math"
+	"testing"
+)
+
+func TestXmlHandlerXPath(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	inXml, err := ioutil.ReadFile("examples/test1_pass.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	xmlHandler, err := NewXmlHandlerMem(inXml, ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xmlHandler.Free()
+
+	res, err := xmlHandler.XPath("/shiporder/item/title", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := res.Nodes()
+	if res.Type != XPathNodeSet || len(nodes) != 2 || nodes[1].Value() != "Hide your heart" || nodes[1].Line() != 17 ||
+		res.String() != "Empire Burlesque" || !res.Bool() {
+		t.Errorf("unexpected node-set result %v", res)
+	}
+
+	res, err = xmlHandler.XPath("sum(//price)", nil)
+	if err != nil || res.Type != XPathNumber || math.Abs(res.Number()-20.8) > 1e-9 {
+		t.Errorf("unexpected number result %v %v", res, err)
+	}
+	res, err = xmlHandler.XPath("string(/shiporder/@orderid)", nil)
+	if err != nil || res.Type != XPathString || res.String() != "889923" || res.Number() != 889923 {
+		t.Errorf("unexpected string result %v %v", res, err)
+	}
+	res, err = xmlHandler.XPath("count(//item) > 1", nil)
+	if err != nil || res.Type != XPathBoolean || !res.Bool() || res.String() != "true" {
+		t.Errorf("unexpected boolean result %v %v", res, err)
+	}
+
+	res, err = nodes[0].XPath("../quantity", nil)
+	if err != nil || res.String() != "1" {
+		t.Errorf("unexpected relative result %v %v", res, err)
+	}
+
+	res, err = xmlHandler.XPath("/", nil)
+	if err != nil || len(res.Nodes()) != 1 || res.Nodes()[0].Name() != (QName{}) || res.Nodes()[0].Path() != "/" {
+		t.Errorf("unexpected document node result %v %v", res, err)
+	}
+
+	if _, err := xmlHandler.XPath("/shiporder[", nil); err == nil {
+		t.Error("expected XPathError")
+	} else if _, ok := err.(XPathError); !ok {
+		t.Errorf("expected XPathError, got %v", err)
+	}
+}
+
+func TestXmlHandlerXPathNamespaces(t *testing.T) {
+	Init()
+	defer Cleanup()
+
+	xmlHandler, err := NewXmlHandlerMem([]byte(`<o:orders xmlns:o="urn:orders" xmlns="urn:default"><o:order id="1"/><order id="2"/></o:orders>`), ParsErrDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xmlHandler.Free()
+
+	res, err := xmlHandler.XPath("/o:orders/o:order/@id", nil)
+	if err != nil || res.String() != "1" {
+		t.Errorf("unexpected result for a root prefix %v %v", res, err)
+	}
+	res, err = xmlHandler.XPath("/o:orders/d:order/@id", map[string]string{"d": "urn:default"})
+	if err != nil || res.String() != "2" || res.Nodes()[0].Name() != (QName{Local: "id"}) {
+		t.Errorf("unexpected result for a registered prefix %v %v", res, err)
+	}
+}